@@ -0,0 +1,110 @@
+package chops
+
+import "sync"
+
+// LazyBroadcaster broadcasts the values from a channel to a
+// changing set of subscribers that connect over time, without
+// ever holding up the source. See MakeLazyBroadcaster.
+type LazyBroadcaster struct {
+	mu     sync.Mutex
+	subs   []chan interface{}
+	pre    []interface{}
+	preCap int
+	closed bool
+	o      options
+}
+
+// MakeLazyBroadcaster starts receiving from the channel in
+// immediately, and sends each value to every subscriber that
+// has room for it in its buffer, without blocking. A
+// subscriber that falls behind misses values instead of
+// stalling the source or the other subscribers.
+//
+// While there are no subscribers, the most recent
+// preBufferCap values are kept in a pre-buffer, and older ones
+// are dropped. The next subscriber receives the pre-buffer
+// first, which empties it. If preBufferCap is 0 or less,
+// values are simply dropped while there are no subscribers.
+// When in is closed, every subscriber's channel is closed.
+// A Tracer sees one send for each subscriber a value reaches,
+// and none for a value that is dropped or pre-buffered.
+//
+// If in is not a channel, MakeLazyBroadcaster will panic.
+func MakeLazyBroadcaster(in interface{}, preBufferCap int, opts ...Option) *LazyBroadcaster {
+	inv := assertChanValue(in)
+	b := &LazyBroadcaster{preCap: preBufferCap, o: applyOptions(opts)}
+	b.o.spawn(func(op *operator) {
+		for {
+			x, ok := inv.Recv()
+			if !ok {
+				b.close()
+				return
+			}
+			b.o.traceRecv(op)
+			b.broadcast(x.Interface())
+		}
+	})
+	return b
+}
+
+func (b *LazyBroadcaster) broadcast(x interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.subs) == 0 {
+		if b.preCap <= 0 {
+			return
+		}
+		if len(b.pre) == b.preCap {
+			b.pre[0] = nil
+			b.pre = b.pre[1:]
+		}
+		b.pre = append(b.pre, x)
+		return
+	}
+	for _, ch := range b.subs {
+		select {
+		case ch <- x:
+			b.o.traceSend()
+		default:
+		}
+	}
+}
+
+func (b *LazyBroadcaster) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for _, ch := range b.subs {
+		close(ch)
+	}
+	b.o.traceClose()
+}
+
+// Subscribe returns a new channel with capacity bufCap that
+// receives every later value, as long as it has room. If this
+// is the first subscriber since the pre-buffer started
+// filling, the pre-buffered values are sent to it first, but
+// only the most recent bufCap of them if it can't hold them
+// all. If the source channel is already closed, the returned
+// channel is closed after any pre-buffered values.
+func (b *LazyBroadcaster) Subscribe(bufCap int) <-chan interface{} {
+	ch := make(chan interface{}, bufCap)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pre := b.pre
+	if len(pre) > bufCap {
+		pre = pre[len(pre)-bufCap:]
+	}
+	for _, x := range pre {
+		ch <- x
+	}
+	b.pre = nil
+
+	if b.closed {
+		close(ch)
+	} else {
+		b.subs = append(b.subs, ch)
+	}
+	return ch
+}
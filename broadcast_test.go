@@ -0,0 +1,99 @@
+package chops
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLazyBroadcasterNoSubscribers(t *testing.T) {
+	in := make(chan int)
+	MakeLazyBroadcaster(in, 0)
+
+	// Nobody is subscribed, but the source must not block
+	for i := 0; i < 10; i++ {
+		select {
+		case in <- i:
+		case <-time.After(time.Second):
+			t.Fatalf("send %d blocked with no subscribers", i)
+		}
+	}
+	close(in)
+}
+
+func TestLazyBroadcasterLateSubscriber(t *testing.T) {
+	in := make(chan int)
+	b := MakeLazyBroadcaster(in, 2)
+	for i := 0; i < 5; i++ {
+		in <- i
+	}
+
+	// The last send can return before the value is broadcast,
+	// so wait for it to reach the pre-buffer
+	eventually(t, "pre-buffer", func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return len(b.pre) == 2 && b.pre[1] == 4
+	})
+
+	// Only the 2 most recent values are replayed
+	early := b.Subscribe(10)
+	if len(early) != 2 {
+		t.Fatalf("first subscriber has %d values replayed, want 2", len(early))
+	}
+	late := b.Subscribe(10)
+	in <- 5
+	close(in)
+
+	var gotEarly, gotLate []interface{}
+	for x := range early {
+		gotEarly = append(gotEarly, x)
+	}
+	for x := range late {
+		gotLate = append(gotLate, x)
+	}
+	if want := []interface{}{3, 4, 5}; !reflect.DeepEqual(gotEarly, want) {
+		t.Errorf("first subscriber received %v, want %v", gotEarly, want)
+	}
+	if want := []interface{}{5}; !reflect.DeepEqual(gotLate, want) {
+		t.Errorf("second subscriber received %v, want %v", gotLate, want)
+	}
+}
+
+func TestLazyBroadcasterSmallSubscriber(t *testing.T) {
+	in := make(chan int)
+	b := MakeLazyBroadcaster(in, 3)
+	for i := 0; i < 3; i++ {
+		in <- i
+	}
+	eventually(t, "pre-buffer", func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return len(b.pre) == 3
+	})
+
+	// A subscriber with room for 1 gets the most recent value
+	sub := b.Subscribe(1)
+	close(in)
+	if got := collect(sub); !reflect.DeepEqual(got, []interface{}{2}) {
+		t.Errorf("subscriber received %v, want [2]", got)
+	}
+}
+
+func TestLazyBroadcasterSlowSubscriber(t *testing.T) {
+	in := make(chan int)
+	b := MakeLazyBroadcaster(in, 0)
+	slow := b.Subscribe(1)
+	fast := b.Subscribe(10)
+	for i := 0; i < 3; i++ {
+		in <- i
+	}
+	close(in)
+
+	if got := collect[interface{}](slow); len(got) != 1 {
+		t.Errorf("slow subscriber received %v, want 1 value", got)
+	}
+	if got := collect[interface{}](fast); len(got) != 3 {
+		t.Errorf("fast subscriber received %v, want 3 values", got)
+	}
+}
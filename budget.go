@@ -0,0 +1,190 @@
+package chops
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// budget limits the number of goroutines that operators can
+// have running at once. A limit of 0 or less is unlimited.
+type budget struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	limit int
+	inUse int
+}
+
+// goroutineBudget holds the *budget that new goroutines are
+// acquired from. Tests swap in a fresh one to be unaffected by
+// goroutines that other tests left running, which keep
+// releasing to the budget they were acquired from.
+var goroutineBudget atomic.Value
+
+func init() {
+	goroutineBudget.Store(newBudget())
+}
+
+func newBudget() *budget {
+	b := &budget{}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func currentBudget() *budget {
+	return goroutineBudget.Load().(*budget)
+}
+
+// fits reports whether n more goroutines fit in the budget.
+// A request for more than the whole budget fits once no other
+// goroutines are running, rather than never. b.mu must be
+// held.
+func (b *budget) fits(n int) bool {
+	return b.limit <= 0 || b.inUse == 0 || b.inUse+n <= b.limit
+}
+
+// acquire blocks until n more goroutines fit in the budget,
+// and then takes them all at once.
+func (b *budget) acquire(n int) {
+	b.mu.Lock()
+	for !b.fits(n) {
+		b.cond.Wait()
+	}
+	b.inUse += n
+	b.mu.Unlock()
+}
+
+// acquireWithin is like acquire, but gives up after d, and
+// reports whether it took the goroutines.
+func (b *budget) acquireWithin(n int, d time.Duration) bool {
+	expired := false
+	timer := time.AfterFunc(d, func() {
+		b.mu.Lock()
+		expired = true
+		b.mu.Unlock()
+		b.cond.Broadcast()
+	})
+	defer timer.Stop()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for !b.fits(n) {
+		if expired {
+			return false
+		}
+		b.cond.Wait()
+	}
+	b.inUse += n
+	return true
+}
+
+func (b *budget) release() {
+	b.mu.Lock()
+	b.inUse--
+	b.mu.Unlock()
+	// Waiters want different amounts, so let them all check
+	b.cond.Broadcast()
+}
+
+// SetGoroutineBudget caps the number of goroutines that all
+// chops operators together can have running at once. Once the
+// cap is reached, constructing another operator blocks until
+// enough existing goroutines have exited. Operators that run
+// several goroutines, such as MergeG or ScatterGather, take
+// all of them from the budget at once, so they never start
+// some of their goroutines and then wait for the rest. An
+// operator that needs more goroutines than the whole cap is
+// started once no other operator goroutines are running, and
+// until then blocks its constructor.
+//
+// Besides operators, the budget covers the goroutines of
+// CollectAll and CollectAllG, and the ones that
+// OverflowCallback runs its callback in. An operator's
+// callback must not wait for a slot that only the operator
+// itself could free, so if the budget has no room for it
+// within the callback's grace period, the callback runs
+// outside the budget instead.
+//
+// The default budget of 0 is unlimited, as is any n less
+// than 0. Lowering the cap below the number of goroutines
+// already running does not stop them, but delays new ones
+// until enough have exited.
+func SetGoroutineBudget(n int) {
+	b := currentBudget()
+	b.mu.Lock()
+	b.limit = n
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// OperatorGoroutines returns the number of goroutines
+// currently running on behalf of chops operators.
+func OperatorGoroutines() int {
+	b := currentBudget()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.inUse
+}
+
+// group is the share of the goroutine budget for all the
+// goroutines of one operator, acquired at once. The operator
+// then starts them one by one with spawn.
+type group struct {
+	b     *budget
+	label string
+	n     int
+}
+
+// newGroup blocks until the budget allows n goroutines, and
+// returns a group that starts up to n goroutines, registered
+// with label while tracking is enabled.
+func newGroup(label string, n int) *group {
+	b := currentBudget()
+	b.acquire(n)
+	return &group{b: b, label: label, n: n}
+}
+
+// spawn runs f in one of the group's goroutines, passing it
+// its registry entry, which is nil while tracking is off.
+func (g *group) spawn(f func(op *operator)) {
+	if g.n <= 0 {
+		panic("operator started more goroutines than it acquired")
+	}
+	g.n--
+	op := register(g.label)
+	go func() {
+		defer g.b.release()
+		defer op.deregister()
+		f(op)
+	}()
+}
+
+// spawn runs f in a new goroutine once the goroutine budget
+// allows it, blocking until then. Every goroutine in the
+// package is started with spawn, spawnOp, or a group for
+// operators that need several, except the ones that
+// OverflowCallback starts without waiting for the budget
+// (see spawnWithin).
+func spawn(f func()) {
+	spawnOp("", func(*operator) { f() })
+}
+
+// spawnWithin is like spawn, but waits no longer than d for
+// the goroutine budget, and then runs f in a goroutine outside
+// the budget instead.
+func spawnWithin(d time.Duration, f func()) {
+	b := currentBudget()
+	if !b.acquireWithin(1, d) {
+		go f()
+		return
+	}
+	g := &group{b: b, n: 1}
+	g.spawn(func(*operator) { f() })
+}
+
+// spawnOp is like spawn, but also registers the goroutine
+// with label while tracking is enabled, and passes f its
+// registry entry, which is nil otherwise.
+func spawnOp(label string, f func(op *operator)) {
+	newGroup(label, 1).spawn(f)
+}
@@ -0,0 +1,119 @@
+package chops
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// freshBudget gives the test a goroutine budget of its own,
+// which goroutines left running by other tests don't count
+// against.
+func freshBudget(t *testing.T) {
+	old := goroutineBudget.Load()
+	goroutineBudget.Store(newBudget())
+	t.Cleanup(func() { goroutineBudget.Store(old) })
+}
+
+func TestSetGoroutineBudget(t *testing.T) {
+	freshBudget(t)
+	SetGoroutineBudget(2)
+
+	ins := make([]chan int, 3)
+	for i := range ins {
+		ins[i] = make(chan int)
+	}
+	MakeOnce(ins[0])
+	MakeOnce(ins[1])
+	if n := OperatorGoroutines(); n != 2 {
+		t.Fatalf("OperatorGoroutines() = %d, want 2", n)
+	}
+
+	// The third operator can't start until another exits
+	started := make(chan struct{})
+	go func() {
+		MakeOnce(ins[2])
+		close(started)
+	}()
+	select {
+	case <-started:
+		t.Fatal("third operator started over budget")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(ins[0])
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("third operator did not start after one exited")
+	}
+	if n := OperatorGoroutines(); n > 2 {
+		t.Errorf("OperatorGoroutines() = %d, want at most 2", n)
+	}
+
+	close(ins[1])
+	close(ins[2])
+	eventually(t, "operators to exit", func() bool {
+		return OperatorGoroutines() == 0
+	})
+}
+
+func TestGoroutineBudgetAllAtOnce(t *testing.T) {
+	freshBudget(t)
+	SetGoroutineBudget(3)
+
+	onceIn := make(chan int)
+	MakeOnce(onceIn)
+
+	// ScatterGather needs 3 goroutines, and none of them may
+	// start while only 2 are free
+	in := make(chan int)
+	started := make(chan (<-chan int))
+	go func() {
+		started <- ScatterGather(context.Background(), 2,
+			func(x int) int { return x }, in)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if n := OperatorGoroutines(); n != 1 {
+		t.Fatalf("OperatorGoroutines() = %d while ScatterGather waits, want 1", n)
+	}
+
+	close(onceIn)
+	var out <-chan int
+	select {
+	case out = <-started:
+	case <-time.After(time.Second):
+		t.Fatal("ScatterGather did not start after MakeOnce exited")
+	}
+	close(in)
+	for range out {
+	}
+	eventually(t, "operators to exit", func() bool {
+		return OperatorGoroutines() == 0
+	})
+}
+
+func TestGoroutineBudgetTooSmall(t *testing.T) {
+	freshBudget(t)
+	SetGoroutineBudget(2)
+
+	// 4 goroutines are more than the whole budget, so they
+	// start together once nothing else is running
+	in := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		in <- i
+	}
+	close(in)
+	out := ScatterGather(context.Background(), 3,
+		func(x int) int { return x * 2 }, in)
+	sum := 0
+	for y := range out {
+		sum += y
+	}
+	if sum != 6 {
+		t.Errorf("sum of results = %d, want 6", sum)
+	}
+	eventually(t, "operators to exit", func() bool {
+		return OperatorGoroutines() == 0
+	})
+}
@@ -6,6 +6,57 @@ import (
 	"time"
 )
 
+func TestIsChan(t *testing.T) {
+	tests := []struct {
+		name string
+		ch   interface{}
+		want bool
+	}{
+		{"Bidirectional", make(chan int), true},
+		{"Send-only", make(chan<- int), true},
+		{"Receive-only", make(<-chan int), true},
+		{"Nil channel", (chan int)(nil), true},
+		{"Nil", nil, false},
+		{"Not a channel", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsChan(tt.ch); got != tt.want {
+				t.Errorf("IsChan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeChan(t *testing.T) {
+	type point struct{ X, Y int }
+	tests := []struct {
+		name string
+		ch   interface{}
+		x    interface{}
+	}{
+		{"MakeChan", MakeChan(reflect.TypeOf(point{}), 1), point{1, 2}},
+		{"MakeChanLike", MakeChanLike(make(<-chan string), 1), "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c := reflect.ValueOf(tt.ch).Cap(); c != 1 {
+				t.Errorf("cap = %d, want 1", c)
+			}
+			if stat := TrySend(tt.ch, tt.x); stat != Ok {
+				t.Fatalf("TrySend() = %v, want Ok", stat)
+			}
+			if x, stat := TryRecv(tt.ch); x != tt.x || stat != Ok {
+				t.Errorf("TryRecv() = %v, %v, want %v, Ok", x, stat, tt.x)
+			}
+		})
+	}
+
+	if _, ok := MakeChanLike(make(chan<- int), 0).(chan int); !ok {
+		t.Error("MakeChanLike() did not make a bidirectional chan int")
+	}
+}
+
 func TestIsClosed(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -349,3 +400,175 @@ func TestSendOr(t *testing.T) {
 		})
 	}
 }
+
+func TestTryRecvInto(t *testing.T) {
+	tests := []struct {
+		name      string
+		chFactory func() interface{}
+		want      string
+		want1     Status
+	}{
+		{
+			"Ok",
+			func() interface{} {
+				ch := make(chan string, 1)
+				ch <- "Hello"
+				return ch
+			},
+			"Hello",
+			Ok,
+		},
+		{
+			"Closed",
+			func() interface{} {
+				ch := make(chan string)
+				close(ch)
+				return ch
+			},
+			"",
+			Closed,
+		},
+		{
+			"Blocked",
+			func() interface{} {
+				return make(chan string)
+			},
+			"untouched",
+			Blocked,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := "untouched"
+			got1 := TryRecvInto(tt.chFactory(), reflect.ValueOf(&got).Elem())
+			if got != tt.want {
+				t.Errorf("TryRecvInto() dst = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("TryRecvInto() = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func TestTryRecvIntoMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		dst  reflect.Value
+	}{
+		{"Wrong type", reflect.ValueOf(new(int)).Elem()},
+		{"Not settable", reflect.ValueOf("")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("TryRecvInto() did not panic")
+				}
+			}()
+			TryRecvInto(make(chan string, 1), tt.dst)
+		})
+	}
+}
+
+func BenchmarkTryRecv(b *testing.B) {
+	ch := make(chan int, 1)
+	var sink int
+	for i := 0; i < b.N; i++ {
+		ch <- i
+		x, _ := TryRecv(ch)
+		sink = x.(int)
+	}
+	_ = sink
+}
+
+func BenchmarkTryRecvInto(b *testing.B) {
+	ch := make(chan int, 1)
+	var sink int
+	dst := reflect.ValueOf(&sink).Elem()
+	for i := 0; i < b.N; i++ {
+		ch <- i
+		TryRecvInto(ch, dst)
+	}
+}
+
+func TestRecvOrInto(t *testing.T) {
+	ch := make(chan int, 1)
+	ch <- 1
+	var dst interface{}
+	if !RecvOrInto(ch, &dst, func() {}) || dst != 1 {
+		t.Errorf("RecvOrInto() stored %v, want 1", dst)
+	}
+
+	// Keep calling f until a value arrives
+	calls := 0
+	if !RecvOrInto(ch, &dst, func() {
+		calls++
+		if calls == 3 {
+			ch <- 2
+		}
+	}) || dst != 2 || calls != 3 {
+		t.Errorf("RecvOrInto() stored %v after %d calls, want 2 after 3", dst, calls)
+	}
+
+	close(ch)
+	if RecvOrInto(ch, &dst, func() {}) || dst != 0 {
+		t.Errorf("RecvOrInto() on closed channel stored %v, want 0", dst)
+	}
+}
+
+// The values start above 255, since smaller ints are boxed
+// without allocating.
+func BenchmarkRecvOr(b *testing.B) {
+	ch := make(chan int, 1)
+	var sum int
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ch <- i + 256
+		x, _ := RecvOr(ch, func() {})
+		sum += x.(int)
+	}
+	_ = sum
+}
+
+func BenchmarkRecvOrInto(b *testing.B) {
+	ch := make(chan int, 1)
+	var sum int
+	var dst interface{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ch <- i + 256
+		RecvOrInto(ch, &dst, func() {})
+		sum += dst.(int)
+	}
+	_ = sum
+}
+
+func testTryCloseG[T any](t *testing.T, ch chan T) {
+	if !TryCloseG(ch) {
+		t.Error("TryCloseG() = false on first close, want true")
+	}
+	if TryCloseG(ch) {
+		t.Error("TryCloseG() = true on second close, want false")
+	}
+}
+
+func TestTryCloseG(t *testing.T) {
+	t.Run("int", func(t *testing.T) {
+		testTryCloseG(t, make(chan int))
+	})
+	t.Run("struct{}", func(t *testing.T) {
+		testTryCloseG(t, make(chan struct{}, 1))
+	})
+	t.Run("pointer", func(t *testing.T) {
+		testTryCloseG(t, make(chan *string))
+	})
+	t.Run("nil", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("TryCloseG() on nil channel did not panic")
+			}
+		}()
+		TryCloseG[int](nil)
+	})
+}
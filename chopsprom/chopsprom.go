@@ -0,0 +1,57 @@
+// Package chopsprom exports the statistics kept by chops
+// operators as Prometheus metrics. It is a separate module, so
+// that the chops package itself does not depend on the
+// Prometheus client.
+package chopsprom
+
+import (
+	"github.com/nik0sc/chops"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type fanCollector struct {
+	stats     *chops.FanStats
+	forwarded *prometheus.Desc
+	dropped   *prometheus.Desc
+	remaining *prometheus.Desc
+}
+
+// NewFanCollector returns a prometheus.Collector that reports
+// the counts in stats, as kept by chops.MakeFanInStats, with
+// the constant labels in labels:
+//
+//	chops_fan_forwarded_total   values sent on the output (counter)
+//	chops_fan_dropped_total     values discarded on overflow (counter)
+//	chops_fan_remaining_inputs  inputs still open (gauge)
+//
+// To register several fan-ins with the same registry, give
+// each of them distinct labels.
+func NewFanCollector(stats *chops.FanStats, labels map[string]string) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(name, help, nil, labels)
+	}
+	return &fanCollector{
+		stats: stats,
+		forwarded: desc("chops_fan_forwarded_total",
+			"Number of values the fan-in has sent on its output."),
+		dropped: desc("chops_fan_dropped_total",
+			"Number of values the fan-in has discarded on overflow."),
+		remaining: desc("chops_fan_remaining_inputs",
+			"Number of the fan-in's inputs that are still open."),
+	}
+}
+
+func (c *fanCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.forwarded
+	ch <- c.dropped
+	ch <- c.remaining
+}
+
+func (c *fanCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.forwarded,
+		prometheus.CounterValue, float64(c.stats.Forwarded()))
+	ch <- prometheus.MustNewConstMetric(c.dropped,
+		prometheus.CounterValue, float64(c.stats.Dropped()))
+	ch <- prometheus.MustNewConstMetric(c.remaining,
+		prometheus.GaugeValue, float64(c.stats.Remaining()))
+}
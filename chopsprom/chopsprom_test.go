@@ -0,0 +1,72 @@
+package chopsprom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nik0sc/chops"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestFanCollector(t *testing.T) {
+	a := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		a <- i
+	}
+	close(a)
+	b := make(chan int)
+	defer close(b)
+
+	var stats chops.FanStats
+	chops.MakeFanInStats(1, &stats, a, b, chops.WithOverflow(chops.DropNewest))
+	deadline := time.Now().Add(time.Second)
+	for stats.Remaining() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("fan-in did not drain its input")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewFanCollector(&stats, map[string]string{"fan": "ingest"}))
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]struct {
+		typ   dto.MetricType
+		value float64
+	}{
+		"chops_fan_forwarded_total":  {dto.MetricType_COUNTER, 1},
+		"chops_fan_dropped_total":    {dto.MetricType_COUNTER, 2},
+		"chops_fan_remaining_inputs": {dto.MetricType_GAUGE, 1},
+	}
+	if len(families) != len(want) {
+		t.Errorf("gathered %d metric families, want %d", len(families), len(want))
+	}
+	for _, mf := range families {
+		w, ok := want[mf.GetName()]
+		if !ok {
+			t.Errorf("unexpected metric family %s", mf.GetName())
+			continue
+		}
+		if mf.GetType() != w.typ {
+			t.Errorf("%s has type %v, want %v", mf.GetName(), mf.GetType(), w.typ)
+		}
+		m := mf.GetMetric()[0]
+		var v float64
+		if w.typ == dto.MetricType_COUNTER {
+			v = m.GetCounter().GetValue()
+		} else {
+			v = m.GetGauge().GetValue()
+		}
+		if v != w.value {
+			t.Errorf("%s = %v, want %v", mf.GetName(), v, w.value)
+		}
+		if l := m.GetLabel(); len(l) != 1 || l[0].GetName() != "fan" || l[0].GetValue() != "ingest" {
+			t.Errorf("%s has labels %v, want fan=ingest", mf.GetName(), l)
+		}
+	}
+}
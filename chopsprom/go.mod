@@ -0,0 +1,26 @@
+module github.com/nik0sc/chops/chopsprom
+
+go 1.18
+
+require (
+	github.com/nik0sc/chops v0.0.0-20261016110408-923ad6cbc9ae
+	github.com/prometheus/client_golang v1.12.2
+	github.com/prometheus/client_model v0.2.0
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.1.2 // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/google/go-cmp v0.5.9 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
+	github.com/prometheus/common v0.32.1 // indirect
+	github.com/prometheus/procfs v0.7.3 // indirect
+	golang.org/x/sys v0.10.0 // indirect
+	google.golang.org/protobuf v1.28.1 // indirect
+)
+
+// The replace only applies when building from this repository,
+// so that chopsprom is developed against the chops next to it.
+// Users of chopsprom get the version required above.
+replace github.com/nik0sc/chops => ../
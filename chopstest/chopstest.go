@@ -0,0 +1,54 @@
+// Package chopstest provides utilities for testing code that
+// makes heavy use of channels.
+package chopstest
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// RunWithDeadlineDetection runs fn and waits up to timeout
+// for it to return. If it does not, the stacks of all
+// goroutines are dumped and the test fails, instead of the
+// whole test binary hanging on a channel operation that will
+// never complete. If fn panics, the panic is propagated to the
+// caller.
+//
+// fn runs in its own goroutine, so it must not call t.FailNow
+// or its relatives. If fn is deadlocked, its goroutine is
+// leaked when RunWithDeadlineDetection returns.
+func RunWithDeadlineDetection(t testing.TB, timeout time.Duration, fn func()) {
+	t.Helper()
+
+	done := make(chan interface{}, 1)
+	go func() {
+		defer func() {
+			done <- recover()
+		}()
+		fn()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		if r != nil {
+			panic(r)
+		}
+	case <-timer.C:
+		t.Fatalf("deadline of %v exceeded, possible deadlock\n\n%s",
+			timeout, allStacks())
+	}
+}
+
+func allStacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,59 @@
+package chopstest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTB records a call to Fatalf instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	msg string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Fatalf(format string, args ...interface{}) {
+	f.msg = fmt.Sprintf(format, args...)
+}
+
+func TestRunWithDeadlineDetection(t *testing.T) {
+	tb := &fakeTB{TB: t}
+	RunWithDeadlineDetection(tb, time.Second, func() {
+		ch := make(chan int, 1)
+		ch <- 1
+		<-ch
+	})
+	if tb.msg != "" {
+		t.Errorf("unexpected failure: %s", tb.msg)
+	}
+}
+
+func TestRunWithDeadlineDetectionDeadlock(t *testing.T) {
+	tb := &fakeTB{TB: t}
+	stuck := make(chan int)
+	defer close(stuck)
+
+	RunWithDeadlineDetection(tb, 10*time.Millisecond, func() {
+		<-stuck
+	})
+	if !strings.Contains(tb.msg, "deadline of 10ms exceeded") {
+		t.Errorf("failure message = %q", tb.msg)
+	}
+	if !strings.Contains(tb.msg, "goroutine ") {
+		t.Error("failure message does not contain a stack dump")
+	}
+}
+
+func TestRunWithDeadlineDetectionPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recovered %v, want boom", r)
+		}
+	}()
+	RunWithDeadlineDetection(t, time.Second, func() {
+		panic("boom")
+	})
+}
@@ -0,0 +1,78 @@
+package chops
+
+import (
+	"context"
+	"sync"
+)
+
+// CollectAll receives every value from every channel in chs
+// until they are all closed, and returns the values in one
+// slice. The channels are read concurrently, and the values of
+// each channel appear in the order they were received, grouped
+// by channel in the order of chs. CollectAll blocks until
+// every channel is closed.
+//
+// If any element of chs is not a channel, CollectAll will
+// panic.
+func CollectAll(chs ...interface{}) []interface{} {
+	cases := recvCases(chs)
+	parts := make([][]interface{}, len(chs))
+	g := newGroup("", len(chs))
+	var wg sync.WaitGroup
+	wg.Add(len(chs))
+	for i := range cases {
+		i := i
+		g.spawn(func(*operator) {
+			defer wg.Done()
+			for {
+				x, ok := cases[i].Chan.Recv()
+				if !ok {
+					return
+				}
+				parts[i] = append(parts[i], x.Interface())
+			}
+		})
+	}
+	wg.Wait()
+
+	var all []interface{}
+	for _, p := range parts {
+		all = append(all, p...)
+	}
+	return all
+}
+
+// CollectAllG is like CollectAll, but for channels whose type
+// is known statically. If ctx is done before every channel is
+// closed, CollectAllG stops receiving and returns the values
+// received so far.
+func CollectAllG[T any](ctx context.Context, chs ...<-chan T) []T {
+	parts := make([][]T, len(chs))
+	g := newGroup("", len(chs))
+	var wg sync.WaitGroup
+	wg.Add(len(chs))
+	for i, ch := range chs {
+		i, ch := i, ch
+		g.spawn(func(*operator) {
+			defer wg.Done()
+			for {
+				select {
+				case x, ok := <-ch:
+					if !ok {
+						return
+					}
+					parts[i] = append(parts[i], x)
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	var all []T
+	for _, p := range parts {
+		all = append(all, p...)
+	}
+	return all
+}
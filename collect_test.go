@@ -0,0 +1,24 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCollectAll(t *testing.T) {
+	a := source(1, 2, 3)
+	b := source[int]()
+	c := source(4, 5, 6, 7, 8)
+	got := CollectAll(a, b, c)
+	if want := []interface{}{1, 2, 3, 4, 5, 6, 7, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CollectAll() = %v, want %v", got, want)
+	}
+}
+
+func TestCollectAllG(t *testing.T) {
+	got := CollectAllG(context.Background(), source("a"), source("b", "c"), source[string]())
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CollectAllG() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package chops
+
+import (
+	"container/list"
+	"context"
+)
+
+// KeyVal is a value tagged with the key it is conflated by in
+// MakeConflatingQueue.
+type KeyVal[K comparable, V any] struct {
+	Key K
+	Val V
+}
+
+// MakeConflatingQueue returns the two ends of a queue that
+// only keeps the latest value for each key. A value sent on in
+// for a key that already has a value waiting replaces it, so
+// the consumer of out never sees a stale value for a key when
+// a newer one is available, however fast updates arrive.
+//
+// The queue holds at most one value per key, so it is bounded
+// by the number of distinct keys. Keys are delivered in the
+// order they were first queued. If moveToBack is true, a key
+// that is updated while waiting moves to the back of the queue
+// instead of keeping its place. Keeping its place means a
+// constantly updated key is still delivered regularly, while
+// moving it to the back favours the keys that have waited
+// longest for a change.
+//
+// When in is closed, the values still queued are delivered,
+// and then out is closed. When ctx is done, out is closed
+// right away, and the values still queued are discarded.
+func MakeConflatingQueue[K comparable, V any](ctx context.Context,
+	moveToBack bool, opts ...Option) (in chan<- KeyVal[K, V], out <-chan KeyVal[K, V]) {
+	o := applyOptions(opts)
+	inCh := make(chan KeyVal[K, V])
+	outCh := make(chan KeyVal[K, V])
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(outCh)
+		queue := list.New()
+		pending := make(map[K]*list.Element)
+		recv := inCh
+		for recv != nil || queue.Len() > 0 {
+			// Only offer a value when there is one
+			var send chan KeyVal[K, V]
+			var head KeyVal[K, V]
+			if queue.Len() > 0 {
+				send = outCh
+				head = queue.Front().Value.(KeyVal[K, V])
+			}
+
+			select {
+			case kv, ok := <-recv:
+				if !ok {
+					recv = nil
+					continue
+				}
+				o.traceRecv(op)
+				if e, ok := pending[kv.Key]; ok {
+					e.Value = kv
+					if moveToBack {
+						queue.MoveToBack(e)
+					}
+				} else {
+					pending[kv.Key] = queue.PushBack(kv)
+				}
+			case send <- head:
+				delete(pending, head.Key)
+				queue.Remove(queue.Front())
+				o.traceSend()
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return inCh, outCh
+}
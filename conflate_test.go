@@ -0,0 +1,52 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMakeConflatingQueue(t *testing.T) {
+	tests := []struct {
+		name       string
+		moveToBack bool
+		want       []KeyVal[string, int]
+	}{
+		{"KeepPlace", false, []KeyVal[string, int]{{"a", 100}, {"b", 2}, {"c", 3}}},
+		{"MoveToBack", true, []KeyVal[string, int]{{"b", 2}, {"c", 3}, {"a", 100}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, out := MakeConflatingQueue[string, int](context.Background(), tt.moveToBack)
+
+			// Nobody reads until every update is in, so "a"
+			// is conflated down to its latest value
+			in <- KeyVal[string, int]{"a", 1}
+			in <- KeyVal[string, int]{"b", 2}
+			in <- KeyVal[string, int]{"c", 3}
+			for i := 2; i <= 100; i++ {
+				in <- KeyVal[string, int]{"a", i}
+			}
+			close(in)
+
+			if got := collect(out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("received %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeConflatingQueueRequeue(t *testing.T) {
+	in, out := MakeConflatingQueue[int, string](context.Background(), false)
+	in <- KeyVal[int, string]{1, "first"}
+	if kv := <-out; kv.Val != "first" {
+		t.Errorf("received %v, want first", kv)
+	}
+
+	// A key that was delivered is queued afresh
+	in <- KeyVal[int, string]{1, "second"}
+	close(in)
+	if got := collect(out); len(got) != 1 || got[0].Val != "second" {
+		t.Errorf("received %v, want only second", got)
+	}
+}
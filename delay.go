@@ -0,0 +1,89 @@
+package chops
+
+import (
+	"reflect"
+	"time"
+)
+
+// MakeDelay forwards values from the channel in to the
+// returned channel with capacity outCap, each one delay after
+// it was received, so the stream keeps its original spacing
+// but is shifted in time. Values are sent in the order they
+// were received. If the consumer falls behind, values are
+// sent as soon as it catches up, and in is still received
+// from in the meantime. When in is closed, the values still
+// waiting are sent at their scheduled times, and then the
+// output is closed.
+//
+// Values wait in an internal queue that is not bounded, so it
+// holds about delay's worth of the input, and grows further
+// while the consumer falls behind. Keep delay short relative
+// to the rate of a fast stream.
+//
+// If in is not a channel, MakeDelay will panic.
+func MakeDelay(outCap int, delay time.Duration, in interface{},
+	opts ...Option) chan interface{} {
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	out := make(chan interface{}, outCap)
+
+	type delayed struct {
+		x  interface{}
+		at time.Time
+	}
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		recvIdx, sendIdx, timerIdx := 0, 1, 2
+		cases := make([]reflect.SelectCase, 3)
+		outv := reflect.ValueOf(out)
+
+		var queue []delayed
+		open := true
+		for open || len(queue) > 0 {
+			if open {
+				cases[recvIdx] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: inv}
+			} else {
+				cases[recvIdx] = reflect.SelectCase{Dir: reflect.SelectRecv}
+			}
+			cases[sendIdx] = reflect.SelectCase{Dir: reflect.SelectRecv}
+			cases[timerIdx] = reflect.SelectCase{Dir: reflect.SelectRecv}
+
+			// Either the head is due and is offered to the
+			// consumer, or we wait until it is
+			var timer *time.Timer
+			if len(queue) > 0 {
+				if wait := time.Until(queue[0].at); wait > 0 {
+					timer = time.NewTimer(wait)
+					cases[timerIdx].Chan = reflect.ValueOf(timer.C)
+				} else {
+					cases[sendIdx] = reflect.SelectCase{
+						Dir:  reflect.SelectSend,
+						Chan: outv,
+						Send: reflect.ValueOf(&queue[0].x).Elem(),
+					}
+				}
+			}
+
+			i, x, ok := reflect.Select(cases)
+			if timer != nil {
+				timer.Stop()
+			}
+			switch {
+			case i == sendIdx:
+				o.traceSend()
+				queue[0] = delayed{}
+				queue = queue[1:]
+			case i == timerIdx:
+			case !ok:
+				open = false
+			default:
+				o.traceRecv(op)
+				queue = append(queue, delayed{x.Interface(), time.Now().Add(delay)})
+			}
+		}
+	})
+
+	return out
+}
@@ -0,0 +1,36 @@
+package chops
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMakeDelay(t *testing.T) {
+	const delay = 30 * time.Millisecond
+	in := make(chan int)
+	sent := make([]time.Time, 5)
+	go func() {
+		defer close(in)
+		for i := range sent {
+			sent[i] = time.Now()
+			in <- i
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	out := MakeDelay(0, delay, in)
+	i := 0
+	for x := range out {
+		now := time.Now()
+		if x != i {
+			t.Fatalf("received %v, want %d", x, i)
+		}
+		if d := now.Sub(sent[i]); d < delay || d > delay+50*time.Millisecond {
+			t.Errorf("value %d delayed by %v, want about %v", i, d, delay)
+		}
+		i++
+	}
+	if i != len(sent) {
+		t.Errorf("received %d values, want %d", i, len(sent))
+	}
+}
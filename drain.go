@@ -0,0 +1,88 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// DrainCtx discards the values buffered in a channel without
+// blocking, and returns the number of values drained and the
+// reason it stopped, whichever happens first:
+// If the return Status is Blocked, the channel is empty.
+// If the return Status is Closed, the channel is closed and
+// empty.
+// If the return Status is Ok, maxDrain values were drained
+// and more may remain. A maxDrain of 0 or less is unlimited.
+// If the return Status is Cancelled, ctx was done.
+//
+// This bounds the time a shutdown path spends on a channel
+// with a large buffer.
+func DrainCtx(ctx context.Context, ch interface{}, maxDrain int) (n int, status Status) {
+	v := assertChanValue(ch)
+	for maxDrain <= 0 || n < maxDrain {
+		if ctx.Err() != nil {
+			return n, Cancelled
+		}
+		x, ok := v.TryRecv()
+		if !ok {
+			if x.IsValid() {
+				return n, Closed
+			}
+			return n, Blocked
+		}
+		n++
+	}
+	return n, Ok
+}
+
+// DrainMap receives from a channel without blocking until it
+// is empty or closed, and returns the received values
+// transformed by f, in order. A closed channel still yields
+// the values buffered before it was closed, so those are
+// included too. This suits flushing a backlog through a
+// serializer during shutdown.
+func DrainMap(ch interface{}, f func(interface{}) interface{}) []interface{} {
+	v := assertChanValue(ch)
+	var mapped []interface{}
+	for {
+		x, ok := v.TryRecv()
+		if !ok {
+			return mapped
+		}
+		mapped = append(mapped, f(x.Interface()))
+	}
+}
+
+// Shutdown stops a pipeline and salvages what is still in
+// flight. It first calls cancel, which should stop every stage
+// of the pipeline, then receives from the pipeline's final
+// output finalOut until it is closed, or until grace has
+// elapsed, and returns the values received in order.
+//
+// Cancelling first means stages blocked on sending downstream
+// are released instead of deadlocking the drain, and draining
+// afterwards collects the values those stages flush, or were
+// already holding in buffers, instead of losing them. If
+// finalOut is never closed, Shutdown still returns after
+// grace.
+//
+// If finalOut is not a channel, Shutdown will panic.
+func Shutdown(finalOut interface{}, cancel func(), grace time.Duration) (leftover []interface{}) {
+	v := assertChanValue(finalOut)
+	cancel()
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: v},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timer.C)},
+	}
+	for {
+		i, x, ok := reflect.Select(cases)
+		if i == 1 || !ok {
+			return leftover
+		}
+		leftover = append(leftover, x.Interface())
+	}
+}
@@ -0,0 +1,170 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDrainCtx(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		chFactory func() interface{}
+		maxDrain  int
+		wantN     int
+		wantStat  Status
+	}{
+		{
+			"Blocked",
+			context.Background(),
+			func() interface{} {
+				ch := make(chan int, 5)
+				ch <- 1
+				ch <- 2
+				return ch
+			},
+			0,
+			2,
+			Blocked,
+		},
+		{
+			"Closed",
+			context.Background(),
+			func() interface{} {
+				ch := make(chan int, 5)
+				ch <- 1
+				close(ch)
+				return ch
+			},
+			0,
+			1,
+			Closed,
+		},
+		{
+			"Budget",
+			context.Background(),
+			func() interface{} {
+				ch := make(chan int, 5)
+				for i := 0; i < 5; i++ {
+					ch <- i
+				}
+				return ch
+			},
+			3,
+			3,
+			Ok,
+		},
+		{
+			"Cancelled",
+			cancelled,
+			func() interface{} {
+				ch := make(chan int, 5)
+				ch <- 1
+				return ch
+			},
+			0,
+			0,
+			Cancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, stat := DrainCtx(tt.ctx, tt.chFactory(), tt.maxDrain)
+			if n != tt.wantN {
+				t.Errorf("DrainCtx() n = %d, want %d", n, tt.wantN)
+			}
+			if stat != tt.wantStat {
+				t.Errorf("DrainCtx() status = %v, want %v", stat, tt.wantStat)
+			}
+		})
+	}
+}
+
+func TestDrainMap(t *testing.T) {
+	double := func(x interface{}) interface{} {
+		return x.(int) * 2
+	}
+	tests := []struct {
+		name      string
+		chFactory func() interface{}
+		want      []interface{}
+	}{
+		{
+			"Open",
+			func() interface{} {
+				ch := make(chan int, 3)
+				ch <- 1
+				ch <- 2
+				return ch
+			},
+			[]interface{}{2, 4},
+		},
+		{
+			"Closed with buffered values",
+			func() interface{} {
+				ch := make(chan int, 3)
+				ch <- 1
+				ch <- 2
+				ch <- 3
+				close(ch)
+				return ch
+			},
+			[]interface{}{2, 4, 6},
+		},
+		{
+			"Empty",
+			func() interface{} {
+				return make(chan int)
+			},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DrainMap(tt.chFactory(), double); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DrainMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// A stage that flushes what it holds once cancelled
+	out := make(chan int, 1)
+	go func() {
+		defer close(out)
+		out <- 1
+		<-ctx.Done()
+		out <- 2
+		out <- 3
+	}()
+
+	got := Shutdown(out, cancel, time.Second)
+	if want := []interface{}{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Shutdown() = %v, want %v", got, want)
+	}
+	if ctx.Err() == nil {
+		t.Error("Shutdown() did not cancel")
+	}
+}
+
+func TestShutdownNeverClosed(t *testing.T) {
+	out := make(chan int, 2)
+	out <- 1
+	const grace = 20 * time.Millisecond
+	start := time.Now()
+	got := Shutdown(out, func() {}, grace)
+	if d := time.Since(start); d < grace || d > time.Second {
+		t.Errorf("Shutdown() returned after %v, want about %v", d, grace)
+	}
+	if want := []interface{}{1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Shutdown() = %v, want %v", got, want)
+	}
+}
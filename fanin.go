@@ -0,0 +1,229 @@
+package chops
+
+import (
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// recvCases returns a receive case for each channel in chs.
+// If any element of chs is not a channel, recvCases will
+// panic.
+func recvCases(chs []interface{}) []reflect.SelectCase {
+	cases := make([]reflect.SelectCase, len(chs))
+	for i, ch := range chs {
+		cases[i] = reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: assertChanValue(ch),
+		}
+	}
+	return cases
+}
+
+// Summary wraps the final accumulator emitted by
+// MakeFanInSummary, so that it can be told apart from the
+// values being merged.
+type Summary struct {
+	Value interface{}
+}
+
+// MakeFanInSummary merges the channels in in into a single
+// output channel with capacity outCap. Starting from init,
+// every forwarded value v is folded into an accumulator with
+// `acc = fold(acc, v)`. Once all the inputs are closed, the
+// final accumulator is sent exactly once, wrapped in a
+// Summary, as the last value before the output is closed.
+//
+// Options may be given among the channels in in.
+// If any other element of in is not a channel,
+// MakeFanInSummary will panic.
+func MakeFanInSummary(outCap int, init interface{},
+	fold func(acc, v interface{}) interface{},
+	in ...interface{}) chan interface{} {
+	in, opts := splitOptions(in)
+	o := applyOptions(opts)
+	cases := recvCases(in)
+	out := make(chan interface{}, outCap)
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		acc := init
+		remaining := len(cases)
+		for remaining > 0 {
+			i, x, ok := doSelect(cases)
+			if !ok {
+				cases[i].Chan = reflect.Value{}
+				remaining--
+				continue
+			}
+			o.traceRecv(op)
+			v := x.Interface()
+			out <- v
+			o.traceSend()
+			acc = fold(acc, v)
+		}
+		out <- Summary{acc}
+		o.traceSend()
+	})
+
+	return out
+}
+
+// MakeFanInIdleClose merges the channels in chs into a single
+// output channel with capacity outCap. An input that produces
+// nothing for perInputIdle is treated as if it had closed and
+// is no longer received from, which protects the merge from an
+// upstream that has gone silent without closing its channel.
+// Inputs are checked for idleness every perInputIdle/2, so an
+// input is dropped after between 1 and 1.5 times perInputIdle.
+// Time spent waiting for the consumer to receive from the
+// output does not count towards any input's idleness.
+//
+// If onIdle is not nil, it is called from the merging
+// goroutine with the index in chs of each input that is
+// dropped for being idle. The output is closed once every
+// input is closed or dropped.
+//
+// Options may be given among the channels in chs, and don't
+// count towards the indexes passed to onIdle.
+//
+// MakeFanInIdleClose will panic if perInputIdle is not
+// positive, or if any other element of chs is not a channel.
+func MakeFanInIdleClose(outCap int, perInputIdle time.Duration,
+	onIdle func(index int), chs ...interface{}) chan interface{} {
+	if perInputIdle <= 0 {
+		panic("idle duration must be positive")
+	}
+	chs, opts := splitOptions(chs)
+	o := applyOptions(opts)
+	// The last case is reserved for the sweep ticker
+	cases := append(recvCases(chs), reflect.SelectCase{})
+	out := make(chan interface{}, outCap)
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+
+		ticker := time.NewTicker(perInputIdle / 2)
+		defer ticker.Stop()
+		tickIdx := len(chs)
+		cases[tickIdx] = reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(ticker.C),
+		}
+
+		now := time.Now()
+		last := make([]time.Time, len(chs))
+		for i := range last {
+			last[i] = now
+		}
+		remaining := len(chs)
+
+		for remaining > 0 {
+			i, x, ok := doSelect(cases)
+			now := time.Now()
+			switch {
+			case i == tickIdx:
+				for j := range last {
+					if cases[j].Chan.IsValid() && now.Sub(last[j]) >= perInputIdle {
+						cases[j].Chan = reflect.Value{}
+						remaining--
+						if onIdle != nil {
+							onIdle(j)
+						}
+					}
+				}
+			case !ok:
+				cases[i].Chan = reflect.Value{}
+				remaining--
+			default:
+				o.traceRecv(op)
+				last[i] = now
+				xi := x.Interface()
+				select {
+				case out <- xi:
+					o.traceSend()
+					continue
+				default:
+				}
+				out <- xi
+				o.traceSend()
+				// Don't blame the inputs for a slow consumer
+				blocked := time.Since(now)
+				for j := range last {
+					last[j] = last[j].Add(blocked)
+				}
+			}
+		}
+	})
+
+	return out
+}
+
+// FanStats counts what a fan-in does with the values from its
+// inputs. Its methods are safe to call while the fan-in is
+// running, for example from a metrics exporter. The zero
+// value is ready to use, but a FanStats should only be given
+// to one fan-in.
+type FanStats struct {
+	forwarded int64
+	dropped   int64
+	remaining int64
+}
+
+// Forwarded returns the number of values sent on the output.
+func (s *FanStats) Forwarded() int64 {
+	return atomic.LoadInt64(&s.forwarded)
+}
+
+// Dropped returns the number of values discarded by the
+// fan-in's overflow policy.
+func (s *FanStats) Dropped() int64 {
+	return atomic.LoadInt64(&s.dropped)
+}
+
+// Remaining returns the number of inputs that are still open.
+func (s *FanStats) Remaining() int64 {
+	return atomic.LoadInt64(&s.remaining)
+}
+
+// MakeFanInStats merges the channels in chs into a single
+// output channel with capacity outCap, and keeps count of its
+// progress in stats. A value that cannot be sent on the
+// output without blocking is handled by the overflow policy
+// given with WithOverflow, Block by default, and counted as
+// dropped if the policy discards it. The output is closed
+// once every input is closed.
+//
+// Options may be given among the channels in chs.
+// If any other element of chs is not a channel,
+// MakeFanInStats will panic.
+func MakeFanInStats(outCap int, stats *FanStats, chs ...interface{}) chan interface{} {
+	chs, opts := splitOptions(chs)
+	o := applyOptions(opts)
+	cases := recvCases(chs)
+	out := make(chan interface{}, outCap)
+	atomic.StoreInt64(&stats.remaining, int64(len(cases)))
+	s := o.sender(out, nil, Block)
+	s.onSend = func() { atomic.AddInt64(&stats.forwarded, 1) }
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer s.close()
+		for atomic.LoadInt64(&stats.remaining) > 0 {
+			i, x, ok := doSelect(cases)
+			if !ok {
+				cases[i].Chan = reflect.Value{}
+				atomic.AddInt64(&stats.remaining, -1)
+				continue
+			}
+			o.traceRecv(op)
+			if !s.send(x) {
+				atomic.AddInt64(&stats.dropped, 1)
+			}
+		}
+	})
+
+	return out
+}
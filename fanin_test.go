@@ -0,0 +1,152 @@
+package chops
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMakeFanInSummary(t *testing.T) {
+	a, b := make(chan int), make(chan int)
+	count := func(acc, _ interface{}) interface{} {
+		return acc.(int) + 1
+	}
+	out := MakeFanInSummary(0, 0, count, a, b)
+
+	go func() {
+		for i := 0; i < 10; i++ {
+			a <- i
+		}
+		close(a)
+	}()
+	go func() {
+		for i := 0; i < 5; i++ {
+			b <- i
+		}
+		close(b)
+	}()
+
+	var values, summaries int
+	var last interface{}
+	for x := range out {
+		if s, ok := x.(Summary); ok {
+			summaries++
+			if s.Value != 15 {
+				t.Errorf("summary = %v, want 15", s.Value)
+			}
+		} else {
+			values++
+		}
+		last = x
+	}
+	if values != 15 {
+		t.Errorf("received %d values, want 15", values)
+	}
+	if summaries != 1 {
+		t.Errorf("received %d summaries, want 1", summaries)
+	}
+	if _, ok := last.(Summary); !ok {
+		t.Errorf("last value = %v, want a Summary", last)
+	}
+}
+
+func TestMakeFanInSummaryNoInputs(t *testing.T) {
+	out := MakeFanInSummary(0, "init", nil)
+	if x := <-out; x != (Summary{"init"}) {
+		t.Errorf("received %v, want Summary{init}", x)
+	}
+	if _, ok := <-out; ok {
+		t.Error("output not closed after summary")
+	}
+}
+
+func TestMakeFanInIdleClose(t *testing.T) {
+	live, silent, closed := make(chan int), make(chan int), make(chan int)
+	close(closed)
+
+	var mu sync.Mutex
+	var idle []int
+	out := MakeFanInIdleClose(0, 50*time.Millisecond, func(i int) {
+		mu.Lock()
+		idle = append(idle, i)
+		mu.Unlock()
+	}, live, silent, closed)
+
+	// Keep live busy past silent's idle period
+	go func() {
+		for i := 0; i < 10; i++ {
+			live <- i
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		if x := <-out; x != i {
+			t.Fatalf("received %v, want %d", x, i)
+		}
+	}
+
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Fatal("received a value, want close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("output not closed after inputs went idle")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []int{1, 0}; !reflect.DeepEqual(idle, want) {
+		t.Errorf("idle inputs = %v, want %v", idle, want)
+	}
+}
+
+func TestMakeFanInIdleCloseSlowConsumer(t *testing.T) {
+	in := make(chan int, 3)
+	in <- 1
+	in <- 2
+	in <- 3
+	out := MakeFanInIdleClose(0, 50*time.Millisecond, nil, in)
+
+	// The merge blocks sending 2 while 3 waits on in, so in
+	// must not be dropped for the consumer being slow
+	<-out
+	time.Sleep(200 * time.Millisecond)
+	for _, want := range []int{2, 3} {
+		if x, ok := <-out; !ok || x != want {
+			t.Errorf("received (%v, %v), want (%d, true)", x, ok, want)
+		}
+	}
+}
+
+func TestMakeFanInStats(t *testing.T) {
+	a, b := make(chan int, 5), make(chan string, 3)
+	for i := 0; i < 5; i++ {
+		a <- i
+	}
+	for i := 0; i < 3; i++ {
+		b <- "b"
+	}
+	c := make(chan int)
+	var stats FanStats
+	out := MakeFanInStats(2, &stats, a, b, c, WithOverflow(DropNewest))
+	close(a)
+	close(b)
+
+	// Nobody is receiving, so only the buffer is forwarded
+	eventually(t, "inputs to drain", func() bool {
+		return stats.Remaining() == 1
+	})
+	if f, d := stats.Forwarded(), stats.Dropped(); f != 2 || d != 6 {
+		t.Errorf("forwarded %d and dropped %d, want 2 and 6", f, d)
+	}
+
+	close(c)
+	if got := collect(out); len(got) != 2 {
+		t.Errorf("received %v, want 2 values", got)
+	}
+	if r := stats.Remaining(); r != 0 {
+		t.Errorf("Remaining() = %d after closing every input", r)
+	}
+}
@@ -0,0 +1,203 @@
+package chops
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Ackable is a value sent by MakeFanOutAcked. The subscriber
+// must call Ack once it has processed Value. Calling Ack more
+// than once has no further effect.
+type Ackable struct {
+	Value interface{}
+	Ack   func()
+}
+
+// MakeFanOutAcked broadcasts every value received from the
+// channel in to n output channels, each with capacity outCap,
+// in lock step: the next value is not received from in until
+// every subscriber has called Ack on the current one. This
+// gives reliable broadcast, such as for a replicated state
+// machine, at the cost of moving at the pace of the slowest
+// subscriber.
+//
+// If ackTimeout is positive, a round of sending a value and
+// waiting for its acknowledgements is given up after that
+// long, so that a subscriber that stops reading or acking
+// cannot stall the others forever. Subscribers that missed the
+// round don't receive the value. If ackTimeout is 0 or less,
+// each round waits indefinitely. When in is closed, every
+// output is closed.
+//
+// If in is not a channel, MakeFanOutAcked will panic.
+func MakeFanOutAcked(n, outCap int, ackTimeout time.Duration, in interface{},
+	opts ...Option) []<-chan Ackable {
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	outs := make([]chan Ackable, n)
+	ret := make([]<-chan Ackable, n)
+	for i := range outs {
+		outs[i] = make(chan Ackable, outCap)
+		ret[i] = outs[i]
+	}
+
+	o.spawn(func(op *operator) {
+		defer func() {
+			for _, ch := range outs {
+				close(ch)
+			}
+			o.traceClose()
+		}()
+
+		// The last two cases are reserved for the round's acks
+		// and its timeout
+		cases := make([]reflect.SelectCase, n+2)
+		ackIdx, timeoutIdx := n, n+1
+		for {
+			x, ok := inv.Recv()
+			if !ok {
+				return
+			}
+			o.traceRecv(op)
+
+			acks := make(chan struct{}, n)
+			for i := range outs {
+				var once sync.Once
+				a := Ackable{
+					Value: x.Interface(),
+					Ack: func() {
+						once.Do(func() { acks <- struct{}{} })
+					},
+				}
+				cases[i] = reflect.SelectCase{
+					Dir:  reflect.SelectSend,
+					Chan: reflect.ValueOf(outs[i]),
+					Send: reflect.ValueOf(a),
+				}
+			}
+			cases[ackIdx] = reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(acks),
+			}
+			cases[timeoutIdx] = reflect.SelectCase{Dir: reflect.SelectRecv}
+			var timer *time.Timer
+			if ackTimeout > 0 {
+				timer = time.NewTimer(ackTimeout)
+				cases[timeoutIdx].Chan = reflect.ValueOf(timer.C)
+			}
+
+			for unacked := n; unacked > 0; {
+				i, _, _ := doSelect(cases)
+				if i == timeoutIdx {
+					break
+				} else if i == ackIdx {
+					unacked--
+				} else {
+					o.traceSend()
+					cases[i].Chan = reflect.Value{}
+				}
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	})
+
+	return ret
+}
+
+// MakeFanOutGrace broadcasts every value received from the
+// channel in to n output channels, each with capacity outCap.
+// The value is offered to every output at once, and each
+// output has sendTimeout to accept it, after which it is
+// skipped for that value. A stalled output therefore delays
+// the others by at most sendTimeout per value, instead of
+// holding them up indefinitely. If sendTimeout is 0 or less,
+// an output is skipped unless it can accept the value without
+// blocking. When in is closed, every output is closed.
+//
+// A value that an output is skipped for is handed to the
+// overflow policy given with WithOverflow, which by default
+// is DropNewest, and so discards it. skipped returns how many
+// values output i has lost so far, that is, how many values
+// the policy left over for it.
+//
+// If in is not a channel, MakeFanOutGrace will panic.
+func MakeFanOutGrace(n, outCap int, sendTimeout time.Duration,
+	in interface{}, opts ...Option) (outs []<-chan interface{}, skipped func(i int) int64) {
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	chs := make([]chan interface{}, n)
+	outs = make([]<-chan interface{}, n)
+	for i := range chs {
+		chs[i] = make(chan interface{}, outCap)
+		outs[i] = chs[i]
+	}
+	skips := make([]int64, n)
+	senders := make([]*sender, n)
+	for i := range chs {
+		senders[i] = o.sender(chs[i], nil, DropNewest)
+	}
+
+	o.spawn(func(op *operator) {
+		defer func() {
+			for _, s := range senders {
+				s.close()
+			}
+			o.traceClose()
+		}()
+
+		// The last case is reserved for the timeout
+		cases := make([]reflect.SelectCase, n+1)
+		timeoutIdx := n
+		for {
+			x, ok := inv.Recv()
+			if !ok {
+				return
+			}
+			o.traceRecv(op)
+
+			v := x.Interface()
+			xv := reflect.ValueOf(&v).Elem()
+
+			// Outputs that are ready don't need a timer
+			pending := 0
+			for i, s := range senders {
+				cases[i] = reflect.SelectCase{Dir: reflect.SelectSend}
+				if !s.trySend(xv) {
+					cases[i] = s.waitCase(xv)
+					pending++
+				}
+			}
+			if pending > 0 && sendTimeout > 0 {
+				timer := time.NewTimer(sendTimeout)
+				cases[timeoutIdx] = reflect.SelectCase{
+					Dir:  reflect.SelectRecv,
+					Chan: reflect.ValueOf(timer.C),
+				}
+				for pending > 0 {
+					i, _, _ := doSelect(cases)
+					if i == timeoutIdx {
+						break
+					}
+					if senders[i].retry(xv) {
+						cases[i].Chan = reflect.Value{}
+						pending--
+					}
+				}
+				timer.Stop()
+			}
+			for i, s := range senders {
+				if cases[i].Chan.IsValid() && s.policy.overflow(s, xv).IsValid() {
+					atomic.AddInt64(&skips[i], 1)
+				}
+			}
+		}
+	})
+
+	return outs, func(i int) int64 {
+		return atomic.LoadInt64(&skips[i])
+	}
+}
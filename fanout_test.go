@@ -0,0 +1,122 @@
+package chops
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMakeFanOutAcked(t *testing.T) {
+	in := make(chan int, 2)
+	in <- 1
+	in <- 2
+	close(in)
+	outs := MakeFanOutAcked(2, 0, 0, in)
+
+	a1 := <-outs[0]
+	b1 := <-outs[1]
+	a1.Ack()
+
+	// Only one subscriber has acked, so 2 must not be sent yet
+	select {
+	case x := <-outs[0]:
+		t.Fatalf("received %v before all acks", x.Value)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	b1.Ack()
+	b1.Ack() // no effect
+	for i, out := range outs {
+		x := <-out
+		if x.Value != 2 {
+			t.Errorf("output %d received %v, want 2", i, x.Value)
+		}
+		x.Ack()
+	}
+	for i, out := range outs {
+		if _, ok := <-out; ok {
+			t.Errorf("output %d not closed", i)
+		}
+	}
+}
+
+func TestMakeFanOutAckedTimeout(t *testing.T) {
+	in := make(chan int, 2)
+	in <- 1
+	in <- 2
+	close(in)
+	outs := MakeFanOutAcked(2, 0, 20*time.Millisecond, in)
+
+	// outs[1] never reads or acks, so outs[0] sees every value
+	// after each round times out
+	var acked int32
+	for x := range outs[0] {
+		x.Ack()
+		atomic.AddInt32(&acked, 1)
+	}
+	if acked != 2 {
+		t.Errorf("outs[0] received %d values, want 2", acked)
+	}
+}
+
+func TestMakeFanOutGrace(t *testing.T) {
+	const n, sendTimeout = 5, 20 * time.Millisecond
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := 0; i < n; i++ {
+			in <- i
+		}
+	}()
+	outs, skipped := MakeFanOutGrace(2, 0, sendTimeout, in)
+
+	// outs[1] is never read, so each value reaches outs[0]
+	// within sendTimeout of the previous one
+	start := time.Now()
+	last := start
+	for i := 0; i < n; i++ {
+		x := <-outs[0]
+		if x != i {
+			t.Fatalf("outs[0] received %v, want %d", x, i)
+		}
+		if d := time.Since(last); d > sendTimeout+50*time.Millisecond {
+			t.Errorf("value %d took %v, want at most about %v", i, d, sendTimeout)
+		}
+		last = time.Now()
+	}
+	if _, ok := <-outs[0]; ok {
+		t.Error("outs[0] not closed")
+	}
+	if _, ok := <-outs[1]; ok {
+		t.Error("outs[1] received a value, want closed")
+	}
+
+	if s := skipped(0); s != 0 {
+		t.Errorf("skipped(0) = %d, want 0", s)
+	}
+	if s := skipped(1); s != n {
+		t.Errorf("skipped(1) = %d, want %d", s, n)
+	}
+}
+
+func TestMakeFanOutGraceNoWait(t *testing.T) {
+	// Without a timeout, only outputs with room get a value
+	buffered, skipped := MakeFanOutGrace(1, 2, 0, source(1, 2))
+	if got := collect(buffered[0]); !reflect.DeepEqual(got, []interface{}{1, 2}) {
+		t.Errorf("buffered output received %v, want [1 2]", got)
+	}
+	if s := skipped(0); s != 0 {
+		t.Errorf("buffered output skipped %d values, want 0", s)
+	}
+
+	unbuffered, skipped := MakeFanOutGrace(2, 0, 0, source(1, 2))
+	eventually(t, "both values to be skipped", func() bool {
+		return skipped(0) == 2 && skipped(1) == 2
+	})
+	for _, out := range unbuffered {
+		if got := collect(out); len(got) != 0 {
+			t.Errorf("unbuffered output received %v, want nothing", got)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package chops
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// assertChanOfChan is like assertChanValue, but also requires
+// the channel's elements to be channels that can be received
+// from.
+func assertChanOfChan(ch interface{}) reflect.Value {
+	v := assertChanValue(ch)
+	elem := v.Type().Elem()
+	if elem.Kind() != reflect.Chan {
+		panic(fmt.Sprintf("not a channel of channels: %T", ch))
+	}
+	if elem.ChanDir()&reflect.RecvDir == 0 {
+		panic(fmt.Sprintf("inner channels are send-only: %T", ch))
+	}
+	return v
+}
+
+// MakeConcatMap flattens a channel of channels, such as a
+// `chan chan interface{}`, into the returned channel with
+// capacity outCap. Each inner channel is received from until
+// it is closed before the next one is taken from chOfCh, so
+// the values of one inner channel never appear before all of
+// those of an earlier one. Inner channels that arrive in the
+// meantime wait on chOfCh, and nil inner channels are skipped.
+// The output is closed when chOfCh is closed and the last
+// inner channel has been drained.
+//
+// If chOfCh is not a channel of channels that can be received
+// from, MakeConcatMap will panic.
+func MakeConcatMap(outCap int, chOfCh interface{}, opts ...Option) chan interface{} {
+	o := applyOptions(opts)
+	outer := assertChanOfChan(chOfCh)
+	out := make(chan interface{}, outCap)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		for {
+			inner, ok := outer.Recv()
+			if !ok {
+				return
+			}
+			if inner.IsNil() {
+				continue
+			}
+			for {
+				x, ok := inner.Recv()
+				if !ok {
+					break
+				}
+				o.traceRecv(op)
+				out <- x.Interface()
+				o.traceSend()
+			}
+		}
+	})
+	return out
+}
+
+// MakeSwitchMap flattens a channel of channels, such as a
+// `chan chan interface{}`, into the returned channel with
+// capacity outCap, always following the latest inner channel.
+// As soon as a new inner channel arrives on chOfCh, the
+// current one is abandoned, along with any value from it that
+// is still waiting to be sent, and values are taken from the
+// new one instead. This suits "latest wins" work such as
+// autocompletion, where a new query supersedes the previous
+// one.
+//
+// An abandoned inner channel is never received from again, so
+// its producer may block forever unless it is cancelled by
+// other means, for example by the same code that sends the
+// next inner channel. The output is closed when chOfCh is
+// closed and the last inner channel is closed too.
+//
+// If chOfCh is not a channel of channels that can be received
+// from, MakeSwitchMap will panic.
+func MakeSwitchMap(outCap int, chOfCh interface{}, opts ...Option) chan interface{} {
+	o := applyOptions(opts)
+	outer := assertChanOfChan(chOfCh)
+	out := make(chan interface{}, outCap)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+
+		const outerIdx, innerIdx, sendIdx = 0, 1, 2
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: outer},
+			{Dir: reflect.SelectRecv},
+			{Dir: reflect.SelectRecv},
+		}
+		outv := reflect.ValueOf(out)
+		var inner reflect.Value
+		var pending interface{}
+		hasPending := false
+
+		for cases[outerIdx].Chan.IsValid() || inner.IsValid() || hasPending {
+			if hasPending {
+				cases[innerIdx].Chan = reflect.Value{}
+				cases[sendIdx] = reflect.SelectCase{
+					Dir:  reflect.SelectSend,
+					Chan: outv,
+					Send: reflect.ValueOf(&pending).Elem(),
+				}
+			} else {
+				cases[innerIdx].Chan = inner
+				cases[sendIdx] = reflect.SelectCase{Dir: reflect.SelectRecv}
+			}
+
+			i, x, ok := reflect.Select(cases)
+			switch i {
+			case outerIdx:
+				if !ok {
+					cases[outerIdx].Chan = reflect.Value{}
+					continue
+				}
+				inner = x
+				if inner.IsNil() {
+					inner = reflect.Value{}
+				}
+				pending, hasPending = nil, false
+			case innerIdx:
+				if !ok {
+					inner = reflect.Value{}
+					continue
+				}
+				o.traceRecv(op)
+				pending, hasPending = x.Interface(), true
+			case sendIdx:
+				o.traceSend()
+				pending, hasPending = nil, false
+			}
+		}
+	})
+	return out
+}
+
+// MakeFlatMap calls f on every value received from the channel
+// in, and sends each element of the slice it returns on the
+// returned channel, which has capacity outCap, in order. f may
+// return any number of values, including none, in which case
+// nothing is sent for that input. All the values from one
+// input are sent before the next input is received. When in
+// is closed, the output is closed.
+//
+// If in is not a channel, MakeFlatMap will panic.
+func MakeFlatMap(outCap int, f func(interface{}) []interface{}, in interface{},
+	opts ...Option) chan interface{} {
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	out := make(chan interface{}, outCap)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		for {
+			x, ok := inv.Recv()
+			if !ok {
+				return
+			}
+			o.traceRecv(op)
+			for _, y := range f(x.Interface()) {
+				out <- y
+				o.traceSend()
+			}
+		}
+	})
+	return out
+}
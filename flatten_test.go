@@ -0,0 +1,104 @@
+package chops
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMakeConcatMap(t *testing.T) {
+	outer := make(chan chan int, 2)
+	first, second := make(chan int), make(chan int, 3)
+	outer <- first
+	outer <- second
+	close(outer)
+
+	// second is ready long before first is done
+	second <- 10
+	second <- 11
+	close(second)
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			first <- i
+		}
+		close(first)
+	}()
+
+	var got []interface{}
+	for x := range MakeConcatMap(0, outer) {
+		got = append(got, x)
+	}
+	if want := []interface{}{0, 1, 2, 10, 11}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MakeConcatMap() = %v, want %v", got, want)
+	}
+}
+
+func TestMakeConcatMapNotNested(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MakeConcatMap() did not panic")
+		}
+	}()
+	MakeConcatMap(0, make(chan int))
+}
+
+func TestMakeConcatMapSendOnly(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MakeConcatMap() did not panic")
+		}
+	}()
+	MakeConcatMap(0, make(chan chan<- int))
+}
+
+func TestMakeSwitchMap(t *testing.T) {
+	outer := make(chan chan string)
+	out := MakeSwitchMap(0, outer)
+
+	first := make(chan string, 2)
+	first <- "a1"
+	first <- "a2"
+	outer <- first
+	if x := <-out; x != "a1" {
+		t.Fatalf("received %v, want a1", x)
+	}
+
+	// a2 is in flight when second supersedes first
+	second := make(chan string, 2)
+	second <- "b1"
+	second <- "b2"
+	close(second)
+	time.Sleep(10 * time.Millisecond)
+	outer <- second
+	close(outer)
+
+	var got []interface{}
+	for x := range out {
+		got = append(got, x)
+	}
+	if want := []interface{}{"b1", "b2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after switch received %v, want %v", got, want)
+	}
+	if len(first) != 0 {
+		t.Errorf("first has %d values left, want 0", len(first))
+	}
+}
+
+func TestMakeFlatMap(t *testing.T) {
+	// Each n expands to n copies of itself, so 0 expands to
+	// nothing
+	repeat := func(x interface{}) []interface{} {
+		n := x.(int)
+		ys := make([]interface{}, n)
+		for i := range ys {
+			ys[i] = n
+		}
+		return ys
+	}
+	got := collect(MakeFlatMap(0, repeat, source(2, 0, 0, 1, 3)))
+	want := []interface{}{2, 2, 1, 3, 3, 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MakeFlatMap() = %v, want %v", got, want)
+	}
+}
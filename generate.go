@@ -0,0 +1,53 @@
+package chops
+
+import (
+	"context"
+	"sync"
+)
+
+// Generate adapts a pull-based source into a channel. It calls
+// produce repeatedly in a goroutine and sends each value on
+// the returned channel, until produce returns false, the
+// returned cancel function is called or ctx is done. The
+// channel is then closed.
+//
+// The goroutine exits promptly after cancel is called or ctx
+// is done, even
+// if nobody is receiving from the channel any more, unless it
+// is in the middle of a call to produce, which it waits for.
+// The value from that call is discarded. cancel may be called
+// any number of times, from any goroutine.
+func Generate[T any](ctx context.Context, produce func() (T, bool)) (<-chan T, func()) {
+	out := make(chan T)
+	done := make(chan struct{})
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() { close(done) })
+	}
+
+	spawn(func() {
+		defer close(out)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			default:
+			}
+			x, ok := produce()
+			if !ok {
+				return
+			}
+			select {
+			case out <- x:
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return out, cancel
+}
@@ -0,0 +1,42 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.uber.org/goleak"
+)
+
+func TestGenerate(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	i := 0
+	out, cancel := Generate(context.Background(), func() (int, bool) {
+		i++
+		return i, i <= 3
+	})
+	defer cancel()
+	if got, want := collect(out), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Generate() = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateCancel(t *testing.T) {
+	ignore := goleak.IgnoreCurrent()
+
+	out, cancel := Generate(context.Background(), func() (string, bool) {
+		return "forever", true
+	})
+	<-out
+	<-out
+
+	// Stop reading altogether, then cancel twice. The
+	// goroutine must exit without anyone receiving
+	cancel()
+	cancel()
+	goleak.VerifyNone(t, ignore)
+	if _, ok := <-out; ok {
+		t.Error("output not closed after cancel")
+	}
+}
@@ -0,0 +1,520 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// The typed operators of the package, the generic ones here
+// and elsewhere as well as MakePercentileWindow, share a
+// lifecycle convention: each one that starts a goroutine takes
+// a context.Context as its first argument, and stops when ctx
+// is done, even if upstream is still producing. Its outputs
+// are then closed promptly, since every send and receive it
+// makes also selects on ctx.Done(), so cancelling a single ctx
+// shared by a composed pipeline stops every stage and leaks no
+// goroutines. The operators on interface{} channels stop only
+// when their input is closed, apart from the few that take a
+// ctx of their own, like AllClosedCtx.
+
+// MapG sends f(x) on the output for every x received from in.
+func MapG[T, U any](ctx context.Context, in <-chan T, f func(T) U, opts ...Option) <-chan U {
+	o := applyOptions(opts)
+	out := make(chan U)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		for {
+			select {
+			case x, ok := <-in:
+				if !ok {
+					return
+				}
+				o.traceRecv(op)
+				select {
+				case out <- f(x):
+					o.traceSend()
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// FilterG forwards the values received from in for which pred
+// returns true, in order.
+func FilterG[T any](ctx context.Context, in <-chan T, pred func(T) bool, opts ...Option) <-chan T {
+	o := applyOptions(opts)
+	out := make(chan T)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		for {
+			select {
+			case x, ok := <-in:
+				if !ok {
+					return
+				}
+				o.traceRecv(op)
+				if !pred(x) {
+					continue
+				}
+				select {
+				case out <- x:
+					o.traceSend()
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// FilterMapG sends the result of f on the output for every
+// value received from in, but only if f also returns true.
+// This transforms and filters in one pass, without a sentinel
+// value between a map and a filter.
+func FilterMapG[T, U any](ctx context.Context, in <-chan T, f func(T) (U, bool),
+	opts ...Option) <-chan U {
+	o := applyOptions(opts)
+	out := make(chan U)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		for {
+			select {
+			case x, ok := <-in:
+				if !ok {
+					return
+				}
+				o.traceRecv(op)
+				y, keep := f(x)
+				if !keep {
+					continue
+				}
+				select {
+				case out <- y:
+					o.traceSend()
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// PairwiseG sends [previous, current] on the output for every
+// value received from in after the first, like MakePairwise.
+func PairwiseG[T any](ctx context.Context, in <-chan T, opts ...Option) <-chan [2]T {
+	o := applyOptions(opts)
+	out := make(chan [2]T)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		var prev T
+		first := true
+		for {
+			select {
+			case x, ok := <-in:
+				if !ok {
+					return
+				}
+				o.traceRecv(op)
+				if first {
+					prev, first = x, false
+					continue
+				}
+				select {
+				case out <- [2]T{prev, x}:
+					o.traceSend()
+					prev = x
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// MakeScanReset folds the values received from in into an
+// accumulator with f, starting from init, and sends the
+// running accumulator on the output after each value. A value
+// for which reset returns true ends the current session, and
+// the accumulator starts again from init for the next value.
+// The final accumulator of each session is therefore the last
+// value sent before the reset, or before the output is closed
+// when in is closed.
+//
+// If includeReset is true, the value that ends a session is
+// folded into it, and its accumulator is sent as usual before
+// the reset. Otherwise, the value only marks the boundary and
+// is neither folded nor causes a send.
+func MakeScanReset[T, A any](ctx context.Context, init A, f func(A, T) A,
+	reset func(T) bool, includeReset bool, in <-chan T, opts ...Option) <-chan A {
+	o := applyOptions(opts)
+	out := make(chan A)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		acc := init
+		for {
+			select {
+			case x, ok := <-in:
+				if !ok {
+					return
+				}
+				o.traceRecv(op)
+				isReset := reset(x)
+				if isReset && !includeReset {
+					acc = init
+					continue
+				}
+				acc = f(acc, x)
+				select {
+				case out <- acc:
+					o.traceSend()
+				case <-ctx.Done():
+					return
+				}
+				if isReset {
+					acc = init
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// BatchG groups the values received from in into slices of
+// length size. When in is closed, any remaining values are
+// sent as a final, shorter batch. If ctx is done, a partial
+// batch is discarded. A batch that the output isn't ready for
+// is handed to the overflow policy given with WithOverflow,
+// Block by default. BatchG will panic if size is not
+// positive.
+func BatchG[T any](ctx context.Context, size int, in <-chan T, opts ...Option) <-chan []T {
+	if size <= 0 {
+		panic("batch size must be positive")
+	}
+	o := applyOptions(opts)
+	out := make(chan []T)
+	s := o.sender(out, ctx.Done(), Block)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer s.close()
+		batch := make([]T, 0, size)
+		for {
+			select {
+			case x, ok := <-in:
+				if !ok {
+					if len(batch) > 0 {
+						s.send(reflect.ValueOf(batch))
+					}
+					return
+				}
+				o.traceRecv(op)
+				batch = append(batch, x)
+				if len(batch) < size {
+					continue
+				}
+				// An OverflowCallback may keep a dropped batch,
+				// so it can't be reused
+				s.send(reflect.ValueOf(batch))
+				if ctx.Err() != nil {
+					return
+				}
+				batch = make([]T, 0, size)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// MergeG forwards the values received from all of chs to a
+// single output, which is closed once every input is closed.
+// Values from the same input keep their order. The inputs are
+// given as a slice, so that Options can follow them.
+func MergeG[T any](ctx context.Context, chs []<-chan T, opts ...Option) <-chan T {
+	return mergeG(ctx, 0, chs, applyOptions(opts))
+}
+
+// FanInTyped merges chs into a single output channel with
+// capacity outCap, like a fan-in, but keeps the element type,
+// so consumers don't need to assert the type of every value.
+// Since every input must have element type T, inputs of any
+// other type are rejected at compile time rather than failing
+// at run time. The output is closed once every input is
+// closed, or when ctx is done. Values from the same input keep
+// their order. Like MergeG, it takes the inputs as a slice.
+func FanInTyped[T any](ctx context.Context, outCap int, chs []<-chan T, opts ...Option) <-chan T {
+	return mergeG(ctx, outCap, chs, applyOptions(opts))
+}
+
+func mergeG[T any](ctx context.Context, outCap int, chs []<-chan T, o options) <-chan T {
+	out := make(chan T, outCap)
+	// One goroutine per input, plus one to close the output
+	g := o.group(len(chs) + 1)
+	var wg sync.WaitGroup
+	wg.Add(len(chs))
+	for _, ch := range chs {
+		ch := ch
+		o.spawnIn(g, func(op *operator) {
+			defer wg.Done()
+			for {
+				select {
+				case x, ok := <-ch:
+					if !ok {
+						return
+					}
+					o.traceRecv(op)
+					select {
+					case out <- x:
+						o.traceSend()
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+	}
+	o.spawnIn(g, func(*operator) {
+		wg.Wait()
+		close(out)
+		o.traceClose()
+	})
+	return out
+}
+
+// MergeBounded is like MergeG, but limits how many values can
+// be in flight, that is, received from the inputs but not yet
+// received by the consumer of the output, to maxInFlight
+// across all the inputs. This bounds the memory held by the
+// merge however many inputs are ready, independently of any
+// buffering. Once the consumer catches up, the merge resumes
+// receiving from the inputs. Values from the same input keep
+// their order. Like MergeG, it takes the inputs as a slice.
+// MergeBounded will panic if maxInFlight is not positive.
+func MergeBounded[T any](ctx context.Context, maxInFlight int, chs []<-chan T,
+	opts ...Option) <-chan T {
+	if maxInFlight <= 0 {
+		panic("maxInFlight must be positive")
+	}
+	o := applyOptions(opts)
+	out := make(chan T)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+
+		// The inputs come first, followed by the send to out
+		// and ctx.Done()
+		open := make([]reflect.Value, len(chs))
+		for i, ch := range chs {
+			open[i] = reflect.ValueOf(ch)
+		}
+		cases := make([]reflect.SelectCase, len(chs)+2)
+		inputs := cases[:len(chs)]
+		for i := range inputs {
+			inputs[i].Dir = reflect.SelectRecv
+		}
+		sendIdx, doneIdx := len(chs), len(chs)+1
+		cases[doneIdx] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+		outv := reflect.ValueOf(out)
+
+		queue := make([]T, 0, maxInFlight)
+		remaining := len(chs)
+		for remaining > 0 || len(queue) > 0 {
+			// Stop receiving while the queue is full by
+			// pointing the input cases at nothing
+			for i := range inputs {
+				if len(queue) < maxInFlight {
+					inputs[i].Chan = open[i]
+				} else {
+					inputs[i].Chan = reflect.Value{}
+				}
+			}
+			if len(queue) > 0 {
+				cases[sendIdx] = reflect.SelectCase{
+					Dir:  reflect.SelectSend,
+					Chan: outv,
+					Send: reflect.ValueOf(&queue[0]).Elem(),
+				}
+			} else {
+				cases[sendIdx] = reflect.SelectCase{Dir: reflect.SelectRecv}
+			}
+
+			i, x, ok := doSelect(cases)
+			switch {
+			case i == doneIdx:
+				return
+			case i == sendIdx:
+				o.traceSend()
+				var zero T
+				queue[0] = zero
+				queue = queue[1:]
+			case !ok:
+				open[i] = reflect.Value{}
+				remaining--
+			default:
+				o.traceRecv(op)
+				var v T
+				reflect.ValueOf(&v).Elem().Set(x)
+				queue = append(queue, v)
+			}
+		}
+	})
+	return out
+}
+
+// ZipWith receives one value from each of a and b in turn, and
+// sends f of the pair on the output, until either input is
+// closed. The output therefore has as many values as the
+// shorter input. If one input is closed while a value from the
+// other is waiting for its pair, that value is discarded, and
+// the longer input is not received from again.
+func ZipWith[A, B, C any](ctx context.Context, f func(A, B) C, a <-chan A, b <-chan B,
+	opts ...Option) <-chan C {
+	o := applyOptions(opts)
+	out := make(chan C)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		for {
+			var x A
+			var y B
+			var ok bool
+			select {
+			case x, ok = <-a:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case y, ok = <-b:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			o.traceRecv(op)
+			select {
+			case out <- f(x, y):
+				o.traceSend()
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// FlatMapG is the typed counterpart of MakeFlatMap. It sends
+// every element of f(x) on the output, in order, for every x
+// received from in.
+func FlatMapG[T, U any](ctx context.Context, in <-chan T, f func(T) []U, opts ...Option) <-chan U {
+	o := applyOptions(opts)
+	out := make(chan U)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		for {
+			select {
+			case x, ok := <-in:
+				if !ok {
+					return
+				}
+				o.traceRecv(op)
+				for _, y := range f(x) {
+					select {
+					case out <- y:
+						o.traceSend()
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// ConcatMapG is like FlatMapG, but f returns a channel, which
+// is received from until it is closed before the next value is
+// taken from in. This streams large expansions instead of
+// holding each one in a slice. A nil channel from f produces
+// nothing. If ctx is done while a channel from f is being
+// received from, that channel is abandoned, so its producer
+// should also watch ctx.
+func ConcatMapG[T, U any](ctx context.Context, in <-chan T, f func(T) <-chan U,
+	opts ...Option) <-chan U {
+	o := applyOptions(opts)
+	out := make(chan U)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		for {
+			select {
+			case x, ok := <-in:
+				if !ok {
+					return
+				}
+				o.traceRecv(op)
+				if !forwardAll(ctx, f(x), out, &o) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// forwardAll sends every value received from in on out until
+// in is closed, tracing the sends with o, and reports false if
+// ctx was done first. A nil in is treated as already closed.
+func forwardAll[T any](ctx context.Context, in <-chan T, out chan<- T, o *options) bool {
+	if in == nil {
+		return true
+	}
+	for {
+		select {
+		case x, ok := <-in:
+			if !ok {
+				return true
+			}
+			select {
+			case out <- x:
+				o.traceSend()
+			case <-ctx.Done():
+				return false
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+// source sends xs on a new channel, then closes it.
+func source[T any](xs ...T) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		for _, x := range xs {
+			ch <- x
+		}
+	}()
+	return ch
+}
+
+// collect receives from ch until it is closed.
+func collect[T any](ch <-chan T) []T {
+	var xs []T
+	for x := range ch {
+		xs = append(xs, x)
+	}
+	return xs
+}
+
+func TestMapG(t *testing.T) {
+	got := collect(MapG(context.Background(), source(1, 2, 3),
+		func(x int) string { return string(rune('a' + x - 1)) }))
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapG() = %v, want %v", got, want)
+	}
+}
+
+func TestFilterG(t *testing.T) {
+	got := collect(FilterG(context.Background(), source(1, 2, 3, 4, 5),
+		func(x int) bool { return x%2 == 1 }))
+	if want := []int{1, 3, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterG() = %v, want %v", got, want)
+	}
+}
+
+func TestFilterMapG(t *testing.T) {
+	got := collect(FilterMapG(context.Background(), source("1", "x", "2", "3"),
+		func(s string) (int, bool) {
+			i, err := strconv.Atoi(s)
+			return i * 10, err == nil
+		}))
+	if want := []int{10, 20, 30}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterMapG() = %v, want %v", got, want)
+	}
+}
+
+func TestPairwiseG(t *testing.T) {
+	got := collect(PairwiseG(context.Background(), source(1, 2, 4, 8)))
+	if want := [][2]int{{1, 2}, {2, 4}, {4, 8}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PairwiseG() = %v, want %v", got, want)
+	}
+	if got := collect(PairwiseG(context.Background(), source(1))); len(got) != 0 {
+		t.Errorf("PairwiseG() on single value = %v, want []", got)
+	}
+}
+
+func TestMakeScanReset(t *testing.T) {
+	sum := func(acc, x int) int { return acc + x }
+	isMarker := func(x int) bool { return x == 0 }
+	in := []int{1, 2, 0, 3, 4, 0, 5}
+
+	got := collect(MakeScanReset(context.Background(), 0, sum, isMarker, false, source(in...)))
+	if want := []int{1, 3, 3, 7, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MakeScanReset(exclude) = %v, want %v", got, want)
+	}
+
+	count := func(acc, _ int) int { return acc + 1 }
+	got = collect(MakeScanReset(context.Background(), 0, count, isMarker, true, source(in...)))
+	if want := []int{1, 2, 3, 1, 2, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MakeScanReset(include) = %v, want %v", got, want)
+	}
+}
+
+func TestBatchG(t *testing.T) {
+	got := collect(BatchG(context.Background(), 2, source(1, 2, 3, 4, 5)))
+	if want := [][]int{{1, 2}, {3, 4}, {5}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("BatchG() = %v, want %v", got, want)
+	}
+}
+
+func TestMergeG(t *testing.T) {
+	got := collect(MergeG(context.Background(), []<-chan int{source(1, 2), source(3), source[int]()}))
+	sort.Ints(got)
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeG() = %v, want %v", got, want)
+	}
+}
+
+func TestFanInTyped(t *testing.T) {
+	type event struct{ id int }
+	a := make(chan event, 2)
+	b := make(chan event, 1)
+	a <- event{1}
+	a <- event{2}
+	b <- event{3}
+	close(a)
+	close(b)
+
+	out := FanInTyped(context.Background(), 3, []<-chan event{a, b})
+	if cap(out) != 3 {
+		t.Errorf("cap(out) = %d, want 3", cap(out))
+	}
+	var ids []int
+	for e := range out {
+		ids = append(ids, e.id)
+	}
+	sort.Ints(ids)
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("FanInTyped() ids = %v, want %v", ids, want)
+	}
+}
+
+func TestFanInTypedCancel(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	ctx, cancel := context.WithCancel(context.Background())
+	a, b := make(chan int), make(chan int)
+	out := FanInTyped(ctx, 0, []<-chan int{a, b})
+
+	// Neither input is ever closed
+	cancel()
+	for range out {
+	}
+}
+
+func TestGenericPipelineCancel(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Endless producers that only stop when cancelled
+	produce := func() <-chan int {
+		ch := make(chan int)
+		go func() {
+			defer close(ch)
+			for i := 0; ; i++ {
+				select {
+				case ch <- i:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch
+	}
+
+	merged := MergeG(ctx, []<-chan int{produce(), produce()})
+	mapped := MapG(ctx, merged, func(x int) int { return x * 2 })
+	filtered := FilterG(ctx, mapped, func(x int) bool { return x%4 == 0 })
+	out := BatchG(ctx, 3, filtered)
+
+	for i := 0; i < 5; i++ {
+		<-out
+	}
+	cancel()
+
+	// out must close promptly, however much is still upstream
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-out:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("output not closed after cancel")
+		}
+	}
+}
+
+// TestTypedOperatorsCancel checks that the typed operators
+// outside this file follow the same convention: cancelling ctx
+// closes their outputs, even though their inputs stay open and
+// nobody reads them.
+func TestTypedOperatorsCancel(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	floats := make(chan float64)
+
+	once := OnceG(ctx, in)
+	tee1, tee2 := TeeOrdered(ctx, 0, in)
+	gen, stop := Generate(ctx, func() (int, bool) { return 0, true })
+	defer stop()
+	_, conflated := MakeConflatingQueue[int, int](ctx, false)
+	pct := MakePercentileWindow(ctx, 0, 3, 0.5, floats)
+	latest := NewLatestG(ctx, in)
+	collected := make(chan []int)
+	go func() { collected <- CollectAllG(ctx, in) }()
+
+	cancel()
+	_, ok, tail := HeadTailG(ctx, in)
+	if ok {
+		t.Error("HeadTailG() got a head after cancel")
+	}
+
+	for _, ch := range []<-chan int{once, tee1, tee2, gen, tail} {
+		for range ch {
+		}
+	}
+	for range conflated {
+	}
+	for range pct {
+	}
+	<-collected
+	eventually(t, "NewLatestG to stop", latest.Closed)
+}
+
+func TestMergeBounded(t *testing.T) {
+	const perInput, maxInFlight = 20, 3
+	inputs := make([]chan int, 3)
+	chs := make([]<-chan int, len(inputs))
+	for i := range inputs {
+		inputs[i] = make(chan int, perInput)
+		for j := 0; j < perInput; j++ {
+			inputs[i] <- j
+		}
+		close(inputs[i])
+		chs[i] = inputs[i]
+	}
+
+	out := MergeBounded(context.Background(), maxInFlight, chs)
+	consumed := 0
+	for {
+		// Give the merge every chance to run ahead
+		time.Sleep(time.Millisecond)
+		pulled := len(inputs) * perInput
+		for _, in := range inputs {
+			pulled -= len(in)
+		}
+		if inFlight := pulled - consumed; inFlight > maxInFlight {
+			t.Fatalf("%d values in flight, want at most %d", inFlight, maxInFlight)
+		}
+		if _, ok := <-out; !ok {
+			break
+		}
+		consumed++
+	}
+	if consumed != len(inputs)*perInput {
+		t.Errorf("consumed %d values, want %d", consumed, len(inputs)*perInput)
+	}
+}
+
+func TestMergeBoundedInterface(t *testing.T) {
+	ch := make(chan error, 2)
+	ch <- nil
+	ch <- context.Canceled
+	close(ch)
+	got := collect(MergeBounded(context.Background(), 1, []<-chan error{ch}))
+	if want := []error{nil, context.Canceled}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeBounded() = %v, want %v", got, want)
+	}
+}
+
+func TestZipWith(t *testing.T) {
+	add := func(a int, b float64) float64 { return float64(a) + b }
+	tests := []struct {
+		name string
+		a    []int
+		b    []float64
+		want []float64
+	}{
+		{"ShorterA", []int{1, 2}, []float64{0.5, 0.25, 0.125}, []float64{1.5, 2.25}},
+		{"ShorterB", []int{1, 2, 3}, []float64{0.5}, []float64{1.5}},
+		{"Empty", nil, []float64{0.5}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Buffered, so the unread rest of the longer input
+			// doesn't leave a sender behind
+			a, b := make(chan int, len(tt.a)), make(chan float64, len(tt.b))
+			for _, x := range tt.a {
+				a <- x
+			}
+			for _, y := range tt.b {
+				b <- y
+			}
+			close(a)
+			close(b)
+			got := collect(ZipWith(context.Background(), add, a, b))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ZipWith() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlatMapG(t *testing.T) {
+	digits := func(s string) []rune { return []rune(s) }
+	got := collect(FlatMapG(context.Background(), source("ab", "", "", "c", "de"), digits))
+	if want := []rune("abcde"); !reflect.DeepEqual(got, want) {
+		t.Errorf("FlatMapG() = %q, want %q", got, want)
+	}
+}
+
+func TestConcatMapG(t *testing.T) {
+	count := func(n int) <-chan int {
+		if n == 0 {
+			return nil
+		}
+		ch := make(chan int)
+		go func() {
+			defer close(ch)
+			for i := 1; i <= n; i++ {
+				ch <- i
+			}
+		}()
+		return ch
+	}
+	got := collect(ConcatMapG(context.Background(), source(2, 0, 3), count))
+	if want := []int{1, 2, 1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ConcatMapG() = %v, want %v", got, want)
+	}
+}
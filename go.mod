@@ -1,3 +1,5 @@
 module github.com/nik0sc/chops
 
-go 1.16
+go 1.18
+
+require go.uber.org/goleak v1.2.1
@@ -0,0 +1,72 @@
+package chops
+
+import "context"
+
+// KeyedStream is one of the streams produced by
+// MakeGroupByOrdered, carrying the values for a single key.
+type KeyedStream[K comparable, T any] struct {
+	Key    K
+	Values <-chan T
+}
+
+// MakeGroupByOrdered splits in into one stream per key, as
+// computed by key. The first time a key is seen, a new
+// KeyedStream for it is sent on the returned channel, and the
+// values with that key are then sent on its Values channel in
+// the order they were received from in. When in is closed or
+// ctx is done, every Values channel and the returned channel
+// are closed.
+//
+// All the channels are unbuffered and fed by a single
+// goroutine, so the consumer must receive every KeyedStream
+// and keep reading each Values channel concurrently, for
+// example with a goroutine per key. A key whose values are not
+// being read holds up every other key.
+func MakeGroupByOrdered[T any, K comparable](ctx context.Context,
+	in <-chan T, key func(T) K, opts ...Option) <-chan KeyedStream[K, T] {
+	o := applyOptions(opts)
+	out := make(chan KeyedStream[K, T])
+	o.spawn(func(op *operator) {
+		streams := make(map[K]chan T)
+		defer func() {
+			for _, ch := range streams {
+				close(ch)
+			}
+			close(out)
+			o.traceClose()
+		}()
+
+		for {
+			var x T
+			var ok bool
+			select {
+			case x, ok = <-in:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			o.traceRecv(op)
+
+			k := key(x)
+			ch, seen := streams[k]
+			if !seen {
+				ch = make(chan T)
+				streams[k] = ch
+				select {
+				case out <- KeyedStream[K, T]{k, ch}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case ch <- x:
+				o.traceSend()
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
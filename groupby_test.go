@@ -0,0 +1,63 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestMakeGroupByOrdered(t *testing.T) {
+	in := source(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	streams := MakeGroupByOrdered(context.Background(), in,
+		func(x int) int { return x % 3 })
+
+	var mu sync.Mutex
+	got := make(map[int][]int)
+	var wg sync.WaitGroup
+	for s := range streams {
+		wg.Add(1)
+		go func(s KeyedStream[int, int]) {
+			defer wg.Done()
+			for x := range s.Values {
+				mu.Lock()
+				got[s.Key] = append(got[s.Key], x)
+				mu.Unlock()
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	want := map[int][]int{
+		0: {3, 6, 9},
+		1: {1, 4, 7, 10},
+		2: {2, 5, 8},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MakeGroupByOrdered() = %v, want %v", got, want)
+	}
+}
+
+func TestMakeGroupByOrderedCancel(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	streams := MakeGroupByOrdered(ctx, in, func(x int) int { return x })
+
+	go func() { in <- 1 }()
+	s := <-streams
+	cancel()
+
+	// Nobody read the value for key 1, but the streams still close
+	select {
+	case <-s.Values:
+	case <-time.After(time.Second):
+		t.Fatal("stream not closed after cancel")
+	}
+	for range streams {
+	}
+}
@@ -0,0 +1,88 @@
+package chops
+
+import "context"
+
+// HeadTail receives the first value from in, blocking until
+// there is one, and returns it as head along with a tail
+// channel that receives every later value from in, in order,
+// and is closed when in is closed. If in is closed without
+// sending anything, ok is false and tail is already closed.
+//
+// No value can slip between head and tail, since in is only
+// ever received from by HeadTail and then by tail's
+// forwarding goroutine, one after the other. Other receivers
+// of in would still take values from both, of course.
+//
+// If in is not a channel, HeadTail will panic.
+func HeadTail(in interface{}, opts ...Option) (head interface{}, ok bool, tail <-chan interface{}) {
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	out := make(chan interface{})
+	x, ok := inv.Recv()
+	if !ok {
+		close(out)
+		o.traceClose()
+		return nil, false, out
+	}
+	// The head is received before there is a goroutine to
+	// count it
+	o.traceRecv(nil)
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		for {
+			x, ok := inv.Recv()
+			if !ok {
+				return
+			}
+			o.traceRecv(op)
+			out <- x.Interface()
+			o.traceSend()
+		}
+	})
+	return x.Interface(), true, out
+}
+
+// HeadTailG is like HeadTail, but typed. If in is closed
+// without sending anything, head is the zero value. If ctx is
+// done before the head arrives, HeadTailG returns as if in
+// had been closed, and once it is done, tail is closed
+// without forwarding the rest of in.
+func HeadTailG[T any](ctx context.Context, in <-chan T, opts ...Option) (head T, ok bool, tail <-chan T) {
+	o := applyOptions(opts)
+	out := make(chan T)
+	select {
+	case head, ok = <-in:
+	case <-ctx.Done():
+	}
+	if !ok {
+		close(out)
+		o.traceClose()
+		return head, false, out
+	}
+	o.traceRecv(nil)
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		for {
+			select {
+			case x, ok := <-in:
+				if !ok {
+					return
+				}
+				o.traceRecv(op)
+				select {
+				case out <- x:
+					o.traceSend()
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return head, true, out
+}
@@ -0,0 +1,57 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestHeadTail(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       []int
+		wantHead interface{}
+		wantOk   bool
+		wantTail []interface{}
+	}{
+		{"Empty", nil, nil, false, nil},
+		{"One", []int{1}, 1, true, nil},
+		{"Many", []int{1, 2, 3, 4}, 1, true, []interface{}{2, 3, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head, ok, tail := HeadTail(source(tt.in...))
+			if head != tt.wantHead || ok != tt.wantOk {
+				t.Errorf("HeadTail() = %v, %v, want %v, %v",
+					head, ok, tt.wantHead, tt.wantOk)
+			}
+			if got := collect(tail); !reflect.DeepEqual(got, tt.wantTail) {
+				t.Errorf("tail = %v, want %v", got, tt.wantTail)
+			}
+		})
+	}
+}
+
+func TestHeadTailG(t *testing.T) {
+	// Buffered, so every value is already waiting when the
+	// tail starts forwarding
+	in := make(chan string, 5)
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		in <- s
+	}
+	close(in)
+
+	head, ok, tail := HeadTailG(context.Background(), in)
+	if head != "a" || !ok {
+		t.Errorf("HeadTailG() = %q, %v, want %q, true", head, ok, "a")
+	}
+	if got, want := collect(tail), []string{"b", "c", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tail = %v, want %v", got, want)
+	}
+
+	empty := make(chan string)
+	close(empty)
+	if head, ok, tail := HeadTailG(context.Background(), empty); head != "" || ok || len(collect(tail)) != 0 {
+		t.Errorf("HeadTailG() of closed channel = %q, %v", head, ok)
+	}
+}
@@ -0,0 +1,52 @@
+package chops
+
+import (
+	"reflect"
+	"time"
+)
+
+// MakeCloseWhenIdle forwards values from the channel in to
+// the returned unbuffered channel, and closes it once no
+// value has arrived on in for the duration idle. Idleness is
+// measured on input arrival only: the timer restarts when a
+// value is received from in, and is not running while that
+// value waits to be received from the output. The output is
+// also closed when in is closed.
+//
+// After the output is closed due to idleness, in is no longer
+// read, so any producer still sending on it may block.
+//
+// If in is not a channel, MakeCloseWhenIdle will panic.
+func MakeCloseWhenIdle(in interface{}, idle time.Duration, opts ...Option) chan interface{} {
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	out := make(chan interface{})
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: inv},
+			{Dir: reflect.SelectRecv},
+		}
+
+		for {
+			// A fresh timer each time avoids depending on
+			// the semantics of Reset for a timer whose
+			// channel may or may not have been drained
+			timer := time.NewTimer(idle)
+			cases[1].Chan = reflect.ValueOf(timer.C)
+			i, x, ok := reflect.Select(cases)
+			timer.Stop()
+			if i == 1 || !ok {
+				return
+			}
+			o.traceRecv(op)
+			out <- x.Interface()
+			o.traceSend()
+		}
+	})
+
+	return out
+}
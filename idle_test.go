@@ -0,0 +1,49 @@
+package chops
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMakeCloseWhenIdle(t *testing.T) {
+	in := make(chan int)
+	out := MakeCloseWhenIdle(in, 50*time.Millisecond)
+
+	// Values arriving more often than the idle period keep
+	// the output open
+	for i := 0; i < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+		in <- i
+		if x := <-out; x != i {
+			t.Fatalf("received %v, want %d", x, i)
+		}
+	}
+
+	start := time.Now()
+	select {
+	case x, ok := <-out:
+		if ok {
+			t.Fatalf("received %v, want close", x)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("output not closed after idle period")
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("output closed after %v, too soon", d)
+	}
+}
+
+func TestMakeCloseWhenIdleInputClosed(t *testing.T) {
+	in := make(chan int)
+	out := MakeCloseWhenIdle(in, time.Hour)
+	close(in)
+
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Fatal("received a value, want close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("output not closed after input closed")
+	}
+}
@@ -0,0 +1,36 @@
+package chops
+
+// ChanInfo is a snapshot of the state of a channel.
+type ChanInfo struct {
+	Len, Cap int
+	// Closed has the same caveats as IsClosed.
+	Closed bool
+	// Utilization is Len/Cap, or 0 for an unbuffered channel.
+	Utilization float64
+}
+
+// Inspect takes a snapshot of the state of each channel in
+// chs, for example for a health check over many channels.
+// Each snapshot is taken separately and the channels may be
+// in use concurrently, so the snapshots are not consistent
+// with each other, and may be out of date as soon as Inspect
+// returns. In particular, as with IsClosed, a channel being
+// closed concurrently may not be reported as closed yet. A nil
+// channel is reported as open and empty.
+//
+// If any element of chs is not a channel, Inspect will panic.
+func Inspect(chs ...interface{}) []ChanInfo {
+	infos := make([]ChanInfo, len(chs))
+	for i, ch := range chs {
+		v := assertChanValue(ch)
+		info := ChanInfo{Len: v.Len(), Cap: v.Cap()}
+		if !v.IsNil() {
+			info.Closed = IsClosed(ch)
+		}
+		if info.Cap > 0 {
+			info.Utilization = float64(info.Len) / float64(info.Cap)
+		}
+		infos[i] = info
+	}
+	return infos
+}
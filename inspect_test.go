@@ -0,0 +1,26 @@
+package chops
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	half := make(chan int, 4)
+	half <- 1
+	half <- 2
+	closed := make(chan string, 1)
+	closed <- "a"
+	close(closed)
+
+	got := Inspect(half, closed, make(chan struct{}), (chan int)(nil))
+	want := []ChanInfo{
+		{Len: 2, Cap: 4, Utilization: 0.5},
+		{Len: 1, Cap: 1, Closed: true, Utilization: 1},
+		{},
+		{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Inspect() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package chops
+
+import (
+	"context"
+	"sync"
+)
+
+// Latest holds the most recent value received from a channel,
+// turning a stream of updates into a current value that can be
+// polled at any time, such as for a gauge or the current
+// configuration.
+type Latest struct {
+	mu     sync.RWMutex
+	x      interface{}
+	ok     bool
+	closed bool
+}
+
+// NewLatest starts a goroutine that receives every value from
+// the channel in and stores it as the latest value. The
+// goroutine exits when in is closed.
+//
+// If in is not a channel, NewLatest will panic.
+func NewLatest(in interface{}) *Latest {
+	inv := assertChanValue(in)
+	l := &Latest{}
+	spawn(func() {
+		for {
+			x, ok := inv.Recv()
+			l.mu.Lock()
+			if !ok {
+				l.closed = true
+				l.mu.Unlock()
+				return
+			}
+			l.x, l.ok = x.Interface(), true
+			l.mu.Unlock()
+		}
+	})
+	return l
+}
+
+// Get returns the latest value, and true if any value has
+// been received yet.
+func (l *Latest) Get() (interface{}, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.x, l.ok
+}
+
+// Closed returns true once the input channel is closed. The
+// latest value remains available from Get.
+func (l *Latest) Closed() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.closed
+}
+
+// LatestG is like Latest, but for a channel whose type is
+// known statically.
+type LatestG[T any] struct {
+	mu     sync.RWMutex
+	x      T
+	ok     bool
+	closed bool
+}
+
+// NewLatestG is like NewLatest, but for a channel whose type
+// is known statically. Once ctx is done, in is no longer
+// received from, as if it had been closed.
+func NewLatestG[T any](ctx context.Context, in <-chan T) *LatestG[T] {
+	l := &LatestG[T]{}
+	spawn(func() {
+		for {
+			var x T
+			var ok bool
+			select {
+			case x, ok = <-in:
+			case <-ctx.Done():
+			}
+			if !ok {
+				break
+			}
+			l.mu.Lock()
+			l.x, l.ok = x, true
+			l.mu.Unlock()
+		}
+		l.mu.Lock()
+		l.closed = true
+		l.mu.Unlock()
+	})
+	return l
+}
+
+// Get returns the latest value, and true if any value has
+// been received yet. Until then, it returns the zero value
+// of T.
+func (l *LatestG[T]) Get() (T, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.x, l.ok
+}
+
+// Closed returns true once the input channel is closed, or
+// the context given to NewLatestG is done. The latest value
+// remains available from Get.
+func (l *LatestG[T]) Closed() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.closed
+}
@@ -0,0 +1,65 @@
+package chops
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// eventually polls cond until it returns true or a second
+// passes.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	in := make(chan int)
+	l := NewLatest(in)
+	if x, ok := l.Get(); ok || x != nil {
+		t.Errorf("Get() = (%v, %v) before any value, want (nil, false)", x, ok)
+	}
+
+	in <- 1
+	in <- 2
+	eventually(t, "latest value", func() bool {
+		x, ok := l.Get()
+		return ok && x == 2
+	})
+	if l.Closed() {
+		t.Error("Closed() = true before close")
+	}
+
+	close(in)
+	eventually(t, "Closed()", l.Closed)
+	if x, ok := l.Get(); !ok || x != 2 {
+		t.Errorf("Get() = (%v, %v) after close, want (2, true)", x, ok)
+	}
+}
+
+func TestLatestG(t *testing.T) {
+	in := make(chan string)
+	l := NewLatestG(context.Background(), in)
+	if x, ok := l.Get(); ok || x != "" {
+		t.Errorf("Get() = (%q, %v) before any value, want (\"\", false)", x, ok)
+	}
+
+	in <- "a"
+	in <- "b"
+	eventually(t, "latest value", func() bool {
+		x, ok := l.Get()
+		return ok && x == "b"
+	})
+
+	close(in)
+	eventually(t, "Closed()", l.Closed)
+	if x, ok := l.Get(); !ok || x != "b" {
+		t.Errorf("Get() = (%q, %v) after close, want (\"b\", true)", x, ok)
+	}
+}
@@ -0,0 +1,82 @@
+package chops
+
+import (
+	"context"
+	"sync"
+)
+
+// ChanMutex is a mutual exclusion lock built on a channel
+// with a buffer of one, which is locked while the buffer is
+// full. Unlike sync.Mutex, it can be acquired in a select
+// statement alongside other channel operations, or abandoned
+// when a context is done. The zero value is an unlocked
+// ChanMutex. A ChanMutex must not be copied after first use.
+type ChanMutex struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+// NewChanMutex returns a new, unlocked ChanMutex.
+func NewChanMutex() *ChanMutex {
+	return &ChanMutex{}
+}
+
+func (m *ChanMutex) init() chan struct{} {
+	m.once.Do(func() {
+		m.ch = make(chan struct{}, 1)
+	})
+	return m.ch
+}
+
+// LockChan returns a channel that acquires the lock when it
+// is sent on, for use in a select statement:
+//
+//	select {
+//	case m.LockChan() <- struct{}{}:
+//		defer m.Unlock()
+//		...
+//	case <-quit:
+//	}
+func (m *ChanMutex) LockChan() chan<- struct{} {
+	return m.init()
+}
+
+// Lock acquires the lock, blocking until it is available.
+func (m *ChanMutex) Lock() {
+	m.init() <- struct{}{}
+}
+
+// TryLock acquires the lock without blocking, and returns
+// whether it did.
+func (m *ChanMutex) TryLock() bool {
+	select {
+	case m.init() <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// LockCtx acquires the lock, blocking until it is available or
+// ctx is done, and returns whether it acquired the lock. If
+// ctx is already done, LockCtx may still acquire an available
+// lock.
+func (m *ChanMutex) LockCtx(ctx context.Context) bool {
+	select {
+	case m.init() <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Unlock releases the lock. Like sync.Mutex, a ChanMutex is
+// not tied to the goroutine that locked it. Unlock will panic
+// if the lock is not held.
+func (m *ChanMutex) Unlock() {
+	select {
+	case <-m.init():
+	default:
+		panic("unlock of unlocked ChanMutex")
+	}
+}
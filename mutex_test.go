@@ -0,0 +1,83 @@
+package chops
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestChanMutex(t *testing.T) {
+	const goroutines, iterations = 8, 1000
+	var m ChanMutex
+	var wg sync.WaitGroup
+	count := 0
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				m.Lock()
+				count++
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if count != goroutines*iterations {
+		t.Errorf("count = %d, want %d", count, goroutines*iterations)
+	}
+}
+
+func TestChanMutexTryLock(t *testing.T) {
+	m := NewChanMutex()
+	if !m.TryLock() {
+		t.Fatal("TryLock() failed on unlocked mutex")
+	}
+	if m.TryLock() {
+		t.Fatal("TryLock() succeeded on locked mutex")
+	}
+	m.Unlock()
+	if !m.TryLock() {
+		t.Fatal("TryLock() failed after Unlock()")
+	}
+	m.Unlock()
+}
+
+func TestChanMutexLockCtx(t *testing.T) {
+	m := NewChanMutex()
+	m.Lock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if m.LockCtx(ctx) {
+		t.Fatal("LockCtx() acquired a held lock")
+	}
+
+	// Releasing the lock lets a waiting LockCtx acquire it
+	acquired := make(chan bool)
+	go func() {
+		acquired <- m.LockCtx(context.Background())
+	}()
+	m.Unlock()
+	if !<-acquired {
+		t.Fatal("LockCtx() failed after Unlock()")
+	}
+
+	select {
+	case m.LockChan() <- struct{}{}:
+		t.Fatal("LockChan() acquired a held lock")
+	default:
+	}
+	m.Unlock()
+}
+
+func TestChanMutexUnlockUnlocked(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Unlock() of unlocked mutex did not panic")
+		}
+	}()
+	var m ChanMutex
+	m.Unlock()
+}
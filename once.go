@@ -0,0 +1,88 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+)
+
+// MakeOnce returns a channel that is closed as soon as the
+// first value is received from the channel in, or in is
+// closed. The value itself is discarded. This turns a data
+// channel into a one-shot signal, such as "the first
+// heartbeat has arrived". The goroutine watching in exits
+// after the signal fires, and in is not read again.
+//
+// If in is not a channel, MakeOnce will panic.
+func MakeOnce(in interface{}) <-chan struct{} {
+	inv := assertChanValue(in)
+	done := make(chan struct{})
+	spawn(func() {
+		defer close(done)
+		inv.Recv()
+	})
+	return done
+}
+
+// OnceG is like MakeOnce, but keeps the first value. The
+// returned channel yields the first value received from in
+// and is then closed. If in is closed before any value
+// arrives, the returned channel is closed without yielding
+// anything. If ctx is done first, the returned channel is
+// closed without yielding anything too, and in is not read
+// again.
+func OnceG[T any](ctx context.Context, in <-chan T) <-chan T {
+	out := make(chan T, 1)
+	spawn(func() {
+		defer close(out)
+		select {
+		case x, ok := <-in:
+			if ok {
+				out <- x
+			}
+		case <-ctx.Done():
+		}
+	})
+	return out
+}
+
+// AllClosed returns a channel that is closed once every
+// channel in chs is closed, like sync.WaitGroup.Wait for
+// channels. Values received from chs in the meantime are
+// discarded. With no channels, the returned channel is closed
+// immediately.
+//
+// If any element of chs is not a channel, AllClosed will
+// panic.
+func AllClosed(chs ...interface{}) <-chan struct{} {
+	return AllClosedCtx(context.Background(), chs...)
+}
+
+// AllClosedCtx is like AllClosed, but also closes the
+// returned channel when ctx is done, even if some of chs are
+// still open. Check ctx.Err() afterwards to tell the two
+// apart. Either way, the goroutine watching chs exits, and
+// chs are not read again.
+func AllClosedCtx(ctx context.Context, chs ...interface{}) <-chan struct{} {
+	// The last case is reserved for ctx
+	cases := append(recvCases(chs), reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(ctx.Done()),
+	})
+	done := make(chan struct{})
+	spawn(func() {
+		defer close(done)
+		doneIdx := len(chs)
+		remaining := len(chs)
+		for remaining > 0 {
+			i, _, ok := reflect.Select(cases)
+			switch {
+			case i == doneIdx:
+				return
+			case !ok:
+				cases[i].Chan = reflect.Value{}
+				remaining--
+			}
+		}
+	})
+	return done
+}
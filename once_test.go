@@ -0,0 +1,109 @@
+package chops
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestMakeOnce(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger func(chan int)
+	}{
+		{"Value", func(ch chan int) { ch <- 1 }},
+		{"Close", func(ch chan int) { close(ch) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+			in := make(chan int, 2)
+			done := MakeOnce(in)
+			select {
+			case <-done:
+				t.Fatal("fired before trigger")
+			case <-time.After(10 * time.Millisecond):
+			}
+
+			tt.trigger(in)
+			select {
+			case _, ok := <-done:
+				if ok {
+					t.Fatal("received a value, want close")
+				}
+			case <-time.After(time.Second):
+				t.Fatal("did not fire after trigger")
+			}
+		})
+	}
+}
+
+func TestOnceG(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	in := make(chan string, 2)
+	in <- "first"
+	in <- "second"
+	got := collect(OnceG(context.Background(), in))
+	if len(got) != 1 || got[0] != "first" {
+		t.Errorf("OnceG() = %v, want [first]", got)
+	}
+	if len(in) != 1 {
+		t.Errorf("%d values left in input, want 1", len(in))
+	}
+
+	closed := make(chan string)
+	close(closed)
+	if got := collect(OnceG(context.Background(), closed)); len(got) != 0 {
+		t.Errorf("OnceG() on closed = %v, want []", got)
+	}
+}
+
+func TestAllClosed(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	a, b := make(chan int), make(chan string, 1)
+	done := AllClosed(a, b)
+	b <- "discarded"
+	close(a)
+	select {
+	case <-done:
+		t.Fatal("closed while b was still open")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(b)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("not closed after every input closed")
+	}
+}
+
+func TestAllClosedNone(t *testing.T) {
+	select {
+	case <-AllClosed():
+	case <-time.After(time.Second):
+		t.Fatal("not closed with no inputs")
+	}
+}
+
+func TestAllClosedCtx(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	open := make(chan int)
+	done := AllClosedCtx(ctx, open)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("not closed after cancel")
+	}
+	if ctx.Err() == nil {
+		t.Error("ctx not done")
+	}
+}
@@ -0,0 +1,108 @@
+package chops
+
+import "runtime"
+
+// Option configures an operator. Every operator that forwards
+// values from its inputs to an output accepts Options, as a
+// variadic list after its required arguments. The fan-ins that
+// already take a variadic list of channels as interface{}
+// values accept Options anywhere in that list instead:
+//
+//	out := MakeFanInSummary(0, 0, sum, ch1, ch2,
+//		WithTracer("merge", t))
+//
+// The typed fan-ins MergeG, FanInTyped and MergeBounded, and
+// Route, take their lists as slices instead, so that Options
+// can follow them. An Option that doesn't apply to an
+// operator, such as WithOverflow for one that doesn't support
+// an OverflowPolicy, is ignored. The signals MakeOnce, OnceG
+// and AllClosed, the sinks CollectAll and CollectAllG,
+// Generate and the Latest holders don't forward values, and
+// take no Options.
+type Option func(*options)
+
+type options struct {
+	stage      string
+	tracer     Tracer
+	overflow   OverflowPolicy
+	lockThread bool
+	// drains are the senders whose rings need a goroutine to
+	// drain them, started along with the operator's goroutine
+	drains []*sender
+}
+
+// splitOptions separates the Options in a fan-in's list of
+// channels from the channels themselves.
+func splitOptions(chs []interface{}) ([]interface{}, []Option) {
+	var opts []Option
+	rest := make([]interface{}, 0, len(chs))
+	for _, ch := range chs {
+		if opt, ok := ch.(Option); ok {
+			opts = append(opts, opt)
+		} else {
+			rest = append(rest, ch)
+		}
+	}
+	return rest, opts
+}
+
+func applyOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithLockedThread makes the operator's goroutine lock itself
+// to its OS thread with runtime.LockOSThread for as long as it
+// runs, and unlock it before exiting. This can reduce the
+// scheduling latency of a latency-sensitive goroutine, such as
+// one mixing real-time audio, but it ties up an OS thread for
+// the lifetime of the operator, and other goroutines can no
+// longer be scheduled onto that thread.
+func WithLockedThread() Option {
+	return func(o *options) {
+		o.lockThread = true
+	}
+}
+
+// lockOSThread and unlockOSThread are variables so that tests
+// can observe WithLockedThread.
+var (
+	lockOSThread   = runtime.LockOSThread
+	unlockOSThread = runtime.UnlockOSThread
+)
+
+// group is like newGroup, but labels the goroutines with the
+// stage name. It also starts the goroutines that drain the
+// rings of the senders created so far, taking them from the
+// goroutine budget together with the operator's n.
+func (o *options) group(n int) *group {
+	g := newGroup(o.stage, n+len(o.drains))
+	for _, s := range o.drains {
+		s := s
+		g.spawn(func(*operator) { s.drain() })
+	}
+	o.drains = nil
+	return g
+}
+
+// spawnIn runs f in one of g's goroutines, and applies the
+// options that affect the goroutine itself.
+func (o *options) spawnIn(g *group, f func(op *operator)) {
+	lock := o.lockThread
+	g.spawn(func(op *operator) {
+		if lock {
+			lockOSThread()
+			defer unlockOSThread()
+		}
+		f(op)
+	})
+}
+
+// spawn runs f in the operator's only goroutine, for the many
+// operators that need just one.
+func (o *options) spawn(f func(op *operator)) {
+	o.spawnIn(o.group(1), f)
+}
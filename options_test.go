@@ -0,0 +1,54 @@
+package chops
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithLockedThread(t *testing.T) {
+	var locked, unlocked int32
+	lock, unlock := lockOSThread, unlockOSThread
+	defer func() {
+		lockOSThread, unlockOSThread = lock, unlock
+	}()
+	lockOSThread = func() {
+		atomic.AddInt32(&locked, 1)
+		lock()
+	}
+	unlockOSThread = func() {
+		unlock()
+		atomic.AddInt32(&unlocked, 1)
+	}
+
+	// fold runs in the merging goroutine, so the thread must
+	// be locked by then
+	var foldLocked int32
+	out := MakeFanInSummary(0, 0, func(acc, v interface{}) interface{} {
+		atomic.StoreInt32(&foldLocked, atomic.LoadInt32(&locked)-atomic.LoadInt32(&unlocked))
+		return acc.(int) + v.(int)
+	}, source(1, 2), WithLockedThread())
+	collect[interface{}](out)
+
+	if n := atomic.LoadInt32(&foldLocked); n != 1 {
+		t.Errorf("%d threads locked while merging, want 1", n)
+	}
+	eventually(t, "thread to be unlocked", func() bool {
+		return atomic.LoadInt32(&unlocked) == 1
+	})
+	if n := atomic.LoadInt32(&locked); n != 1 {
+		t.Errorf("LockOSThread called %d times, want 1", n)
+	}
+}
+
+func TestWithoutLockedThread(t *testing.T) {
+	var locked int32
+	defer func(lock func()) { lockOSThread = lock }(lockOSThread)
+	lockOSThread = func() { atomic.AddInt32(&locked, 1) }
+
+	collect[interface{}](MakeFanInSummary(0, 0, func(acc, v interface{}) interface{} {
+		return acc
+	}, source(1, 2)))
+	if n := atomic.LoadInt32(&locked); n != 0 {
+		t.Errorf("LockOSThread called %d times without WithLockedThread", n)
+	}
+}
@@ -0,0 +1,422 @@
+package chops
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// OverflowPolicy decides what an operator does with a value
+// that it cannot send without blocking, because its output
+// is full or has no receiver waiting. Operators that support
+// a policy accept it as an Option with WithOverflow. These
+// are MakeFanOutGrace, MakeTokenBucket, BatchG,
+// MakeFanInStats, TeeSynced and TeeOrdered, and unless
+// documented otherwise, they default to Block.
+//
+// A policy either finds room for the value, or leaves a value
+// over: the value itself, or in the case of DropOldest, the
+// older value that it evicted to make room. A policy succeeds
+// if it leaves nothing over. ChainPolicy hands whatever one
+// policy leaves over to the next, and the operator discards
+// what is left over at the end.
+type OverflowPolicy interface {
+	// overflow handles x, which could not be sent by s
+	// without blocking, and returns the value that it leaves
+	// over, or the zero Value if it leaves nothing.
+	overflow(s *sender, x reflect.Value) (rest reflect.Value)
+}
+
+type blockPolicy struct{}
+
+func (blockPolicy) overflow(s *sender, x reflect.Value) reflect.Value {
+	if s.wait(x, reflect.Value{}) {
+		return reflect.Value{}
+	}
+	return x
+}
+
+type dropPolicy struct{}
+
+func (dropPolicy) overflow(_ *sender, x reflect.Value) reflect.Value {
+	return x
+}
+
+type dropOldestPolicy struct{}
+
+func (dropOldestPolicy) overflow(s *sender, x reflect.Value) reflect.Value {
+	return s.evict(x)
+}
+
+var (
+	// Block waits until the value can be sent. No values are
+	// lost, and the operator applies backpressure upstream.
+	// Block always succeeds, unless the operator is stopped
+	// by its context while waiting.
+	Block OverflowPolicy = blockPolicy{}
+	// DropNewest discards the value that could not be sent.
+	// DropNewest always fails.
+	DropNewest OverflowPolicy = dropPolicy{}
+	// DropOldest keeps the most recent values for the
+	// consumer. An operator with DropOldest holds values that
+	// its output isn't ready for in a buffer of its own, as
+	// large as the output's capacity but at least one value,
+	// and a goroutine of its own sends them on as the
+	// consumer catches up. When that buffer is full, the
+	// oldest value in it is evicted to make room. DropOldest
+	// leaves the evicted value over, so it fails, but for the
+	// older value rather than the new one. Values already in
+	// the output channel are never taken back from it.
+	DropOldest OverflowPolicy = dropOldestPolicy{}
+)
+
+type blockForPolicy time.Duration
+
+func (d blockForPolicy) overflow(s *sender, x reflect.Value) reflect.Value {
+	timer := time.NewTimer(time.Duration(d))
+	defer timer.Stop()
+	if s.wait(x, reflect.ValueOf(timer.C)) {
+		return reflect.Value{}
+	}
+	return x
+}
+
+// BlockFor waits up to d for the value to be sent, and
+// succeeds if it was. Unlike Block, it applies backpressure
+// only briefly, so it is useful at the start of a ChainPolicy
+// to ride out short stalls in the consumer.
+func BlockFor(d time.Duration) OverflowPolicy {
+	return blockForPolicy(d)
+}
+
+// callbackGrace bounds how long an OverflowCallback may hold
+// up the operator that called it.
+var callbackGrace = 100 * time.Millisecond
+
+type callbackPolicy func(dropped interface{})
+
+func (f callbackPolicy) overflow(_ *sender, x reflect.Value) reflect.Value {
+	// Waiting for the goroutine budget counts towards the
+	// grace period
+	timer := time.NewTimer(callbackGrace)
+	defer timer.Stop()
+	done := make(chan struct{})
+	spawnWithin(callbackGrace, func() {
+		defer close(done)
+		f(x.Interface())
+	})
+
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+	return x
+}
+
+// OverflowCallback discards the value that could not be sent,
+// but first hands it to f, which may log it, count it or
+// route it elsewhere. The operator waits for f to return
+// before continuing, but for no longer than a short grace
+// period (currently 100ms), after which f carries on in the
+// background. A slow or stuck f therefore cannot block the
+// data path indefinitely, but f may run concurrently with
+// itself if it overruns the grace period. Each call to f runs
+// in a goroutine of its own, which counts against the
+// goroutine budget (see SetGoroutineBudget). Waiting for the
+// budget is part of the grace period, and if the budget has
+// no room by the end of it, f runs outside the budget rather
+// than hold up the operator.
+func OverflowCallback(f func(dropped interface{})) OverflowPolicy {
+	return callbackPolicy(f)
+}
+
+type chainPolicy []OverflowPolicy
+
+func (c chainPolicy) overflow(s *sender, x reflect.Value) reflect.Value {
+	for _, p := range c {
+		if x = p.overflow(s, x); !x.IsValid() {
+			break
+		}
+	}
+	return x
+}
+
+// ChainPolicy combines policies into one that applies each
+// of them in turn, handing what one leaves over to the next,
+// until one succeeds. The chain fails, and the value left
+// over is discarded, if they all fail. Since Block always
+// succeeds, no policy after it is ever applied. DropNewest
+// and OverflowCallback always fail, and BlockFor would send
+// a value that DropOldest evicted after newer ones, so only
+// DropNewest and OverflowCallback make sense after DropOldest.
+// For example, to wait briefly for the consumer, then evict
+// the oldest buffered value, and finally log the value that
+// was evicted:
+//
+//	ChainPolicy(BlockFor(10*time.Millisecond), DropOldest,
+//		OverflowCallback(logDropped))
+//
+// A ChainPolicy of no policies behaves like DropNewest.
+func ChainPolicy(policies ...OverflowPolicy) OverflowPolicy {
+	return chainPolicy(append([]OverflowPolicy(nil), policies...))
+}
+
+// WithOverflow sets the policy an operator applies to values
+// it cannot send without blocking.
+func WithOverflow(p OverflowPolicy) Option {
+	return func(o *options) {
+		o.overflow = p
+	}
+}
+
+// sender sends an operator's values on one of its outputs,
+// and hands the values that the output isn't ready for to the
+// operator's overflow policy. An operator creates its senders
+// before it spawns its goroutine, so that the goroutine each
+// sender needs for DropOldest is acquired along with it, and
+// closes its outputs through them.
+type sender struct {
+	o      *options
+	out    reflect.Value
+	done   reflect.Value
+	policy OverflowPolicy
+	// ring holds the values waiting to be sent on out, if
+	// the policy uses DropOldest
+	ring *ring
+	// onSend, if not nil, is called after each value is sent
+	// on out
+	onSend func()
+}
+
+// sender returns a sender for out. A policy that waits gives
+// up once done is closed, if done is not nil. def is the
+// policy used if the operator was given none.
+func (o *options) sender(out interface{}, done <-chan struct{}, def OverflowPolicy) *sender {
+	s := &sender{o: o, out: reflect.ValueOf(out), policy: o.overflow}
+	if done != nil {
+		s.done = reflect.ValueOf(done)
+	}
+	if s.policy == nil {
+		s.policy = def
+	}
+	if usesRing(s.policy) {
+		size := s.out.Cap()
+		if size < 1 {
+			size = 1
+		}
+		s.ring = newRing(size)
+		o.drains = append(o.drains, s)
+	}
+	return s
+}
+
+func usesRing(p OverflowPolicy) bool {
+	switch p := p.(type) {
+	case dropOldestPolicy:
+		return true
+	case chainPolicy:
+		for _, q := range p {
+			if usesRing(q) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// send sends x, applying the overflow policy if that would
+// block. It reports whether no value had to be discarded.
+func (s *sender) send(x reflect.Value) bool {
+	if s.trySend(x) {
+		return true
+	}
+	return !s.policy.overflow(s, x).IsValid()
+}
+
+func (s *sender) sent() {
+	s.o.traceSend()
+	if s.onSend != nil {
+		s.onSend()
+	}
+}
+
+// trySend sends x without blocking, or with a ring, queues it
+// if there is room, and reports whether it did.
+func (s *sender) trySend(x reflect.Value) bool {
+	r := s.ring
+	if r == nil {
+		if !s.out.TrySend(x) {
+			return false
+		}
+		s.sent()
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Only skip the ring if that can't overtake a value in it
+	if r.n == 0 && !r.busy && s.out.TrySend(x) {
+		s.sent()
+		return true
+	}
+	if r.n == len(r.buf) {
+		return false
+	}
+	r.push(x)
+	signal(r.ready)
+	return true
+}
+
+// waitCase returns a select case that is ready when s may be
+// ready for x. Once it is selected, call retry.
+func (s *sender) waitCase(x reflect.Value) reflect.SelectCase {
+	if s.ring != nil {
+		return reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(s.ring.space),
+		}
+	}
+	return reflect.SelectCase{Dir: reflect.SelectSend, Chan: s.out, Send: x}
+}
+
+// retry follows the selection of s.waitCase(x), and reports
+// whether x has now been sent.
+func (s *sender) retry(x reflect.Value) bool {
+	if s.ring != nil {
+		return s.trySend(x)
+	}
+	s.sent()
+	return true
+}
+
+// wait sends x, blocking until it is sent, done is closed or
+// timeout, which may be the zero Value, receives. It reports
+// whether x was sent.
+func (s *sender) wait(x, timeout reflect.Value) bool {
+	for {
+		// A case on the zero Value is ignored
+		i, _, _ := doSelect([]reflect.SelectCase{
+			s.waitCase(x),
+			{Dir: reflect.SelectRecv, Chan: s.done},
+			{Dir: reflect.SelectRecv, Chan: timeout},
+		})
+		if i != 0 {
+			return false
+		}
+		if s.retry(x) {
+			return true
+		}
+	}
+}
+
+// evict queues x in the ring, evicting the oldest value in it
+// if it is full, and returns the evicted value. Without a
+// ring, x itself is left over.
+func (s *sender) evict(x reflect.Value) reflect.Value {
+	r := s.ring
+	if r == nil {
+		return x
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var old reflect.Value
+	if r.n == len(r.buf) {
+		old = r.pop()
+	}
+	r.push(x)
+	signal(r.ready)
+	return old
+}
+
+// close closes the output once every value in the ring has
+// been sent, or right away without a ring.
+func (s *sender) close() {
+	r := s.ring
+	if r == nil {
+		s.out.Close()
+		return
+	}
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	signal(r.ready)
+}
+
+// drain is the goroutine that sends the values in the ring on
+// out, until the sender is closed and the ring is empty, or
+// done is closed.
+func (s *sender) drain() {
+	r := s.ring
+	defer s.out.Close()
+	wake := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.ready)},
+		{Dir: reflect.SelectRecv, Chan: s.done},
+	}
+	send := []reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: s.out},
+		{Dir: reflect.SelectRecv, Chan: s.done},
+	}
+	for {
+		r.mu.Lock()
+		for r.n == 0 && !r.closed {
+			r.mu.Unlock()
+			if i, _, _ := doSelect(wake); i != 0 {
+				return
+			}
+			r.mu.Lock()
+		}
+		if r.n == 0 {
+			r.mu.Unlock()
+			return
+		}
+		send[0].Send = r.pop()
+		r.busy = true
+		r.mu.Unlock()
+		signal(r.space)
+
+		i, _, _ := doSelect(send)
+		send[0].Send = reflect.Value{}
+		if i != 0 {
+			return
+		}
+		s.sent()
+		r.mu.Lock()
+		r.busy = false
+		r.mu.Unlock()
+	}
+}
+
+// ring is a fixed-size FIFO of values waiting to be sent. The
+// ready and space channels are signalled when a value is
+// added and when one is taken out.
+type ring struct {
+	mu     sync.Mutex
+	buf    []reflect.Value
+	head   int
+	n      int
+	busy   bool // a value taken out is still being sent
+	closed bool
+	ready  chan struct{}
+	space  chan struct{}
+}
+
+func newRing(size int) *ring {
+	return &ring{
+		buf:   make([]reflect.Value, size),
+		ready: make(chan struct{}, 1),
+		space: make(chan struct{}, 1),
+	}
+}
+
+func (r *ring) push(x reflect.Value) {
+	r.buf[(r.head+r.n)%len(r.buf)] = x
+	r.n++
+}
+
+func (r *ring) pop() reflect.Value {
+	x := r.buf[r.head]
+	r.buf[r.head] = reflect.Value{}
+	r.head = (r.head + 1) % len(r.buf)
+	r.n--
+	return x
+}
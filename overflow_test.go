@@ -0,0 +1,363 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOverflowPolicy(t *testing.T) {
+	var dropped []interface{}
+	tests := []struct {
+		name     string
+		policy   OverflowPolicy
+		wantSent bool
+		wantLen  int
+		wantDrop []interface{}
+	}{
+		{"Default", nil, true, 1, nil},
+		{"Block", Block, true, 1, nil},
+		{"DropNewest", DropNewest, false, 1, nil},
+		{
+			"Callback",
+			OverflowCallback(func(x interface{}) {
+				dropped = append(dropped, x)
+			}),
+			false,
+			1,
+			[]interface{}{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dropped = nil
+			var opts []Option
+			if tt.policy != nil {
+				opts = append(opts, WithOverflow(tt.policy))
+			}
+			o := applyOptions(opts)
+
+			out := make(chan int, 1)
+			s := o.sender(out, nil, Block)
+			if !s.send(reflect.ValueOf(1)) {
+				t.Fatal("first send failed")
+			}
+
+			// Make room for a blocking send after a while
+			if tt.wantSent {
+				time.AfterFunc(10*time.Millisecond, func() { <-out })
+			}
+			if sent := s.send(reflect.ValueOf(2)); sent != tt.wantSent {
+				t.Errorf("send() = %v, want %v", sent, tt.wantSent)
+			}
+			if len(out) != tt.wantLen {
+				t.Errorf("len(out) = %d, want %d", len(out), tt.wantLen)
+			}
+			if !reflect.DeepEqual(dropped, tt.wantDrop) {
+				t.Errorf("dropped = %v, want %v", dropped, tt.wantDrop)
+			}
+		})
+	}
+}
+
+func TestOverflowCallbackGrace(t *testing.T) {
+	old := callbackGrace
+	callbackGrace = 10 * time.Millisecond
+	defer func() { callbackGrace = old }()
+
+	var returned int32
+	release := make(chan struct{})
+	o := applyOptions([]Option{WithOverflow(OverflowCallback(func(interface{}) {
+		<-release
+		atomic.StoreInt32(&returned, 1)
+	}))})
+
+	start := time.Now()
+	o.sender(make(chan int), nil, Block).send(reflect.ValueOf(1))
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("stuck callback blocked send for %v", d)
+	}
+	if atomic.LoadInt32(&returned) != 0 {
+		t.Error("callback returned before it was released")
+	}
+	close(release)
+}
+
+// pressure sends 1 to 7 with s, whose output has capacity 2
+// and is never read. 1 and 2 fill the output, and 3 stays in
+// flight from the ring until the output is read, so 4 to 7
+// go through a full ring. It returns what send reported for
+// each value.
+func pressure(t *testing.T, o *options, s *sender) []bool {
+	o.spawn(func(*operator) {})
+	var sent []bool
+	for i := 1; i <= 7; i++ {
+		sent = append(sent, s.send(reflect.ValueOf(i)))
+		if i == 3 {
+			eventually(t, "3 to be in flight", func() bool {
+				s.ring.mu.Lock()
+				defer s.ring.mu.Unlock()
+				return s.ring.busy
+			})
+		}
+	}
+	return sent
+}
+
+func TestOverflowCallbackBudget(t *testing.T) {
+	freshBudget(t)
+	SetGoroutineBudget(1)
+	old := callbackGrace
+	callbackGrace = 10 * time.Millisecond
+	defer func() { callbackGrace = old }()
+
+	// The operator holds the only goroutine, so the callback
+	// has to run outside the budget
+	in := make(chan int)
+	var dropped dropLog
+	out := MakeTokenBucket(1, 1000, 10, in, WithOverflow(OverflowCallback(dropped.add)))
+	for i := 0; i < 3; i++ {
+		select {
+		case in <- i:
+		case <-time.After(time.Second):
+			t.Fatalf("send of %d blocked by the callback", i)
+		}
+	}
+	close(in)
+	eventually(t, "values to be dropped", func() bool {
+		return len(dropped.get()) == 2
+	})
+	collect(out)
+}
+
+func TestOverflowPolicyDropOldest(t *testing.T) {
+	out := make(chan int, 2)
+	o := applyOptions([]Option{WithOverflow(DropOldest)})
+	s := o.sender(out, nil, Block)
+
+	// 6 and 7 evict 4 and 5 from the ring, so their sends fail
+	sent := pressure(t, &o, s)
+	want := []bool{true, true, true, true, true, false, false}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("send() returned %v, want %v", sent, want)
+	}
+	s.close()
+	if got := collect[int](out); !reflect.DeepEqual(got, []int{1, 2, 3, 6, 7}) {
+		t.Errorf("out received %v, want [1 2 3 6 7]", got)
+	}
+}
+
+func TestChainPolicy(t *testing.T) {
+	t.Run("BlockFor", func(t *testing.T) {
+		// The consumer keeps up within BlockFor, so nothing is
+		// evicted
+		var dropped dropLog
+		o := applyOptions([]Option{WithOverflow(ChainPolicy(
+			BlockFor(time.Minute),
+			DropOldest,
+			OverflowCallback(dropped.add),
+		))})
+		out := make(chan int)
+		s := o.sender(out, nil, Block)
+		o.spawn(func(*operator) {
+			defer s.close()
+			for i := 1; i <= 5; i++ {
+				if !s.send(reflect.ValueOf(i)) {
+					t.Errorf("send(%d) failed", i)
+				}
+			}
+		})
+		if got := collect[int](out); !reflect.DeepEqual(got, []int{1, 2, 3, 4, 5}) {
+			t.Errorf("out received %v, want [1 2 3 4 5]", got)
+		}
+		if got := dropped.get(); len(got) != 0 {
+			t.Errorf("dropped = %v, want nothing", got)
+		}
+	})
+
+	t.Run("DropOldest", func(t *testing.T) {
+		// The consumer is stuck, so the ring keeps the most
+		// recent values and the evicted ones go to the callback
+		var dropped dropLog
+		o := applyOptions([]Option{WithOverflow(ChainPolicy(
+			BlockFor(time.Millisecond),
+			DropOldest,
+			OverflowCallback(dropped.add),
+		))})
+		out := make(chan int, 2)
+		s := o.sender(out, nil, Block)
+		pressure(t, &o, s)
+		s.close()
+		if got := collect[int](out); !reflect.DeepEqual(got, []int{1, 2, 3, 6, 7}) {
+			t.Errorf("out received %v, want [1 2 3 6 7]", got)
+		}
+		if want := []interface{}{4, 5}; !reflect.DeepEqual(dropped.get(), want) {
+			t.Errorf("dropped = %v, want %v", dropped.get(), want)
+		}
+	})
+
+	t.Run("Callback", func(t *testing.T) {
+		// Without DropOldest, every value that BlockFor gives
+		// up on ends up with the callback
+		var dropped dropLog
+		o := applyOptions([]Option{WithOverflow(ChainPolicy(
+			BlockFor(time.Millisecond),
+			OverflowCallback(dropped.add),
+		))})
+		s := o.sender(make(chan int), nil, Block)
+		for i := 1; i <= 3; i++ {
+			if s.send(reflect.ValueOf(i)) {
+				t.Errorf("send(%d) succeeded with nobody receiving", i)
+			}
+		}
+		if want := []interface{}{1, 2, 3}; !reflect.DeepEqual(dropped.get(), want) {
+			t.Errorf("dropped = %v, want %v", dropped.get(), want)
+		}
+	})
+}
+
+func TestChainPolicyEmpty(t *testing.T) {
+	o := applyOptions([]Option{WithOverflow(ChainPolicy())})
+	if o.sender(make(chan int), nil, Block).send(reflect.ValueOf(1)) {
+		t.Error("send() with an empty chain succeeded")
+	}
+}
+
+// dropLog records the values passed to an OverflowCallback,
+// which runs in a goroutine of its own.
+type dropLog struct {
+	mu sync.Mutex
+	xs []interface{}
+}
+
+func (d *dropLog) add(x interface{}) {
+	d.mu.Lock()
+	d.xs = append(d.xs, x)
+	d.mu.Unlock()
+}
+
+func (d *dropLog) get() []interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]interface{}(nil), d.xs...)
+}
+
+func TestOverflowBlockCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	o := applyOptions(nil)
+	s := o.sender(make(chan int), ctx.Done(), Block)
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if s.send(reflect.ValueOf(1)) {
+		t.Error("send() succeeded with nobody receiving")
+	}
+}
+
+func TestMakeTokenBucketOverflow(t *testing.T) {
+	in := make(chan int)
+	var dropped dropLog
+	out := MakeTokenBucket(1, 1000, 10, in, WithOverflow(OverflowCallback(dropped.add)))
+
+	// Nobody receives, so only the first value fits
+	for i := 0; i < 3; i++ {
+		in <- i
+	}
+	close(in)
+	// Receiving would make room for a value not yet dropped
+	eventually(t, "values to be dropped", func() bool {
+		return len(dropped.get()) == 2
+	})
+	if got := collect(out); !reflect.DeepEqual(got, []interface{}{0}) {
+		t.Errorf("out received %v, want [0]", got)
+	}
+	if want := []interface{}{1, 2}; !reflect.DeepEqual(dropped.get(), want) {
+		t.Errorf("dropped = %v, want %v", dropped.get(), want)
+	}
+}
+
+func TestBatchGOverflow(t *testing.T) {
+	var dropped dropLog
+	out := BatchG(context.Background(), 2, source(1, 2, 3, 4, 5),
+		WithOverflow(OverflowCallback(dropped.add)))
+
+	// Nobody receives from the unbuffered output until the
+	// input is exhausted
+	eventually(t, "batches to be dropped", func() bool {
+		return len(dropped.get()) == 3
+	})
+	if got := collect(out); len(got) != 0 {
+		t.Errorf("out received %v, want nothing", got)
+	}
+	if want := []interface{}{[]int{1, 2}, []int{3, 4}, []int{5}}; !reflect.DeepEqual(dropped.get(), want) {
+		t.Errorf("dropped = %v, want %v", dropped.get(), want)
+	}
+}
+
+func TestMakeFanOutGraceOverflow(t *testing.T) {
+	in := make(chan int)
+	var dropped dropLog
+	outs, skipped := MakeFanOutGrace(2, 1, 0, in, WithOverflow(OverflowCallback(dropped.add)))
+
+	// Output 1 is never read, so it misses every value after
+	// the first
+	for i := 0; i < 3; i++ {
+		in <- i
+		if x := <-outs[0]; x != i {
+			t.Fatalf("output 0 received %v, want %d", x, i)
+		}
+	}
+	close(in)
+	<-outs[0]
+	if n := skipped(1); n != 2 {
+		t.Errorf("skipped(1) = %d, want 2", n)
+	}
+	if want := []interface{}{1, 2}; !reflect.DeepEqual(dropped.get(), want) {
+		t.Errorf("dropped = %v, want %v", dropped.get(), want)
+	}
+}
+
+func TestTeeOrderedOverflow(t *testing.T) {
+	in := make(chan int)
+	var dropped dropLog
+	out1, out2 := TeeOrdered(context.Background(), 1, in, WithOverflow(OverflowCallback(dropped.add)))
+
+	// out2 is never read, but doesn't hold back out1
+	for i := 0; i < 3; i++ {
+		in <- i
+		if x := <-out1; x != i {
+			t.Fatalf("out1 received %v, want %d", x, i)
+		}
+	}
+	close(in)
+	eventually(t, "values to be dropped", func() bool {
+		return len(dropped.get()) == 2
+	})
+	if got := collect(out2); !reflect.DeepEqual(got, []int{0}) {
+		t.Errorf("out2 received %v, want [0]", got)
+	}
+	if want := []interface{}{1, 2}; !reflect.DeepEqual(dropped.get(), want) {
+		t.Errorf("dropped = %v, want %v", dropped.get(), want)
+	}
+}
+
+func TestMakeFanOutGraceDropOldest(t *testing.T) {
+	in := make(chan int)
+	outs, skipped := MakeFanOutGrace(1, 1, 0, in, WithOverflow(DropOldest))
+
+	// Nobody reads until the input closes, so the output and
+	// the ring fill up, and then the oldest value in the ring
+	// is evicted for each new one
+	for i := 0; i < 5; i++ {
+		in <- i
+	}
+	close(in)
+	got := collect(outs[0])
+	if n := len(got); n < 2 || got[n-1] != 4 {
+		t.Errorf("out received %v, want at least 2 values ending in 4", got)
+	}
+	if n := skipped(0); int(n)+len(got) != 5 {
+		t.Errorf("skipped(0) = %d with %d values received, want 5 in all", n, len(got))
+	}
+}
@@ -0,0 +1,99 @@
+package chops
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MakePercentileWindow sends, after every value received from
+// in, the p-th percentile of the last n values received, or of
+// every value so far until n have been received. p is a
+// fraction between 0 and 1, so 0.5 gives the median. The
+// percentile is taken by nearest rank: it is the smallest
+// value in the window that is greater than or equal to a
+// fraction p of the values in the window. The returned
+// channel has capacity outCap, and is closed when in is
+// closed or ctx is done.
+//
+// The window is kept sorted as well as in arrival order, so
+// each value costs O(log n) comparisons to place, plus moving
+// up to n values along. NaN has no place in the order, so in
+// must not send it.
+//
+// MakePercentileWindow will panic if n is less than 1, or if
+// p is not between 0 and 1.
+func MakePercentileWindow(ctx context.Context, outCap, n int, p float64,
+	in <-chan float64, opts ...Option) <-chan float64 {
+	if n < 1 {
+		panic(fmt.Sprintf("invalid window size %d", n))
+	}
+	if !(p >= 0 && p <= 1) {
+		panic(fmt.Sprintf("invalid percentile %v", p))
+	}
+	o := applyOptions(opts)
+	out := make(chan float64, outCap)
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		ring := make([]float64, 0, n)
+		sorted := make([]float64, 0, n)
+		oldest := 0
+		for {
+			var x float64
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				x = v
+				o.traceRecv(op)
+			case <-ctx.Done():
+				return
+			}
+			if len(ring) < n {
+				ring = append(ring, x)
+			} else {
+				sorted = removeSorted(sorted, ring[oldest])
+				ring[oldest] = x
+				oldest = (oldest + 1) % n
+			}
+			sorted = insertSorted(sorted, x)
+			select {
+			case out <- nearestRank(sorted, p):
+				o.traceSend()
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return out
+}
+
+func insertSorted(s []float64, x float64) []float64 {
+	i := sort.SearchFloat64s(s, x)
+	s = append(s, 0)
+	copy(s[i+1:], s[i:])
+	s[i] = x
+	return s
+}
+
+// removeSorted removes one occurrence of x, which must be in
+// s.
+func removeSorted(s []float64, x float64) []float64 {
+	i := sort.SearchFloat64s(s, x)
+	return append(s[:i], s[i+1:]...)
+}
+
+// nearestRank returns the p-th percentile of the sorted,
+// non-empty s.
+func nearestRank(s []float64, p float64) float64 {
+	rank := int(math.Ceil(p * float64(len(s))))
+	if rank < 1 {
+		rank = 1
+	}
+	return s[rank-1]
+}
@@ -0,0 +1,64 @@
+package chops
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// bruteForcePercentile sorts a copy of window and picks the
+// nearest rank directly.
+func bruteForcePercentile(window []float64, p float64) float64 {
+	s := append([]float64(nil), window...)
+	sort.Float64s(s)
+	for i, x := range s {
+		if float64(i+1) >= p*float64(len(s)) {
+			return x
+		}
+	}
+	return s[len(s)-1]
+}
+
+func TestMakePercentileWindow(t *testing.T) {
+	const n, count = 50, 500
+	r := rand.New(rand.NewSource(1))
+	xs := make([]float64, count)
+	for i := range xs {
+		// Plenty of duplicates, so removal must pick the
+		// right copy
+		xs[i] = math.Floor(r.Float64() * 100)
+	}
+
+	for _, p := range []float64{0, 0.5, 0.99, 1} {
+		out := MakePercentileWindow(context.Background(), 0, n, p, source(xs...))
+		i := 0
+		for got := range out {
+			lo := i + 1 - n
+			if lo < 0 {
+				lo = 0
+			}
+			if want := bruteForcePercentile(xs[lo:i+1], p); got != want {
+				t.Fatalf("p=%v: value %d = %v, want %v", p, i, got, want)
+			}
+			i++
+		}
+		if i != count {
+			t.Errorf("p=%v: received %d values, want %d", p, i, count)
+		}
+	}
+}
+
+func TestMakePercentileWindowInvalid(t *testing.T) {
+	for _, p := range []float64{-0.1, 1.1, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("MakePercentileWindow(p=%v) did not panic", p)
+				}
+			}()
+			MakePercentileWindow(context.Background(), 0, 1, p, make(chan float64))
+		}()
+	}
+}
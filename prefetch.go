@@ -0,0 +1,121 @@
+package chops
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// MakeAutoPrefetch forwards values from the channel in to the
+// returned unbuffered channel, prefetching up to a target
+// number of values from in ahead of the consumer. Unlike a
+// fixed buffer, the target adapts to the consumer, between
+// minCap and maxCap. It starts at minCap, and doubles once the
+// consumer has taken as many values as the target without
+// making the forwarder wait, which shows the consumer keeping
+// up. It halves whenever the forwarder has prefetched the
+// target and has to wait for the consumer, which shows the
+// consumer lagging, so a slow consumer isn't buffered for
+// needlessly. When in is closed, the values already
+// prefetched are forwarded, and then the output is closed.
+//
+// target returns the current target, for monitoring.
+//
+// MakeAutoPrefetch will panic if minCap is less than 1, if
+// maxCap is less than minCap, or if in is not a channel.
+func MakeAutoPrefetch(minCap, maxCap int, in interface{},
+	opts ...Option) (out chan interface{}, target func() int) {
+	if minCap < 1 || maxCap < minCap {
+		panic(fmt.Sprintf("invalid prefetch bounds: minCap=%d maxCap=%d", minCap, maxCap))
+	}
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	out = make(chan interface{})
+	current := int64(minCap)
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		recvIdx, sendIdx := 0, 1
+		cases := make([]reflect.SelectCase, 2)
+		outv := reflect.ValueOf(out)
+
+		var queue []interface{}
+		pop := func() {
+			o.traceSend()
+			queue[0] = nil
+			queue = queue[1:]
+		}
+		target := minCap
+		setTarget := func(t int) {
+			target = t
+			atomic.StoreInt64(&current, int64(t))
+		}
+		open := true
+		// Values the consumer has taken without waiting since
+		// the target last changed
+		ready := 0
+
+		for open || len(queue) > 0 {
+			if len(queue) > 0 {
+				select {
+				case out <- queue[0]:
+					pop()
+					ready++
+					if ready >= target && target < maxCap {
+						t := 2 * target
+						if t > maxCap {
+							t = maxCap
+						}
+						setTarget(t)
+						ready = 0
+					}
+					continue
+				default:
+				}
+			}
+
+			// The consumer isn't ready, so see whether that
+			// matters yet
+			full := len(queue) >= target
+			if open && full && target > minCap {
+				t := target / 2
+				if t < minCap {
+					t = minCap
+				}
+				setTarget(t)
+				ready = 0
+			}
+
+			if open && !full {
+				cases[recvIdx] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: inv}
+			} else {
+				cases[recvIdx] = reflect.SelectCase{Dir: reflect.SelectRecv}
+			}
+			if len(queue) > 0 {
+				cases[sendIdx] = reflect.SelectCase{
+					Dir:  reflect.SelectSend,
+					Chan: outv,
+					Send: reflect.ValueOf(&queue[0]).Elem(),
+				}
+			} else {
+				cases[sendIdx] = reflect.SelectCase{Dir: reflect.SelectRecv}
+			}
+
+			i, x, ok := reflect.Select(cases)
+			switch {
+			case i == sendIdx:
+				pop()
+			case !ok:
+				open = false
+			default:
+				o.traceRecv(op)
+				queue = append(queue, x.Interface())
+			}
+		}
+	})
+
+	return out, func() int {
+		return int(atomic.LoadInt64(&current))
+	}
+}
@@ -0,0 +1,65 @@
+package chops
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMakeAutoPrefetch(t *testing.T) {
+	const minCap, maxCap = 1, 16
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		// Trickle values to a consumer that is always
+		// waiting, then flood a slow one
+		for i := 0; i < 100; i++ {
+			in <- i
+			time.Sleep(100 * time.Microsecond)
+		}
+		for i := 100; i < 200; i++ {
+			in <- i
+		}
+	}()
+	out, target := MakeAutoPrefetch(minCap, maxCap, in)
+	if got := target(); got != minCap {
+		t.Errorf("initial target = %d, want %d", got, minCap)
+	}
+
+	next := 0
+	recv := func() {
+		if x := <-out; x != next {
+			t.Fatalf("received %v, want %d", x, next)
+		}
+		next++
+	}
+	for next < 100 {
+		recv()
+	}
+	if got := target(); got != maxCap {
+		t.Errorf("target with a fast consumer = %d, want %d", got, maxCap)
+	}
+
+	for next < 130 {
+		time.Sleep(time.Millisecond)
+		recv()
+	}
+	if got := target(); got != minCap {
+		t.Errorf("target with a slow consumer = %d, want %d", got, minCap)
+	}
+
+	for next < 200 {
+		recv()
+	}
+	if _, ok := <-out; ok {
+		t.Error("output not closed")
+	}
+}
+
+func TestMakeAutoPrefetchBounds(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MakeAutoPrefetch() did not panic")
+		}
+	}()
+	MakeAutoPrefetch(4, 2, make(chan int))
+}
@@ -0,0 +1,112 @@
+package chops
+
+import (
+	"sync"
+	"time"
+)
+
+type record struct {
+	x  interface{}
+	at time.Time
+}
+
+// Recorder passes values through from a channel while
+// recording each one with the time it was received, so that
+// the stream can later be played back with its original
+// timing. This helps to reproduce timing-dependent bugs in a
+// pipeline.
+type Recorder struct {
+	out chan interface{}
+	o   options
+
+	mu      sync.Mutex
+	records []record
+	start   int // index of the oldest record once limit is reached
+	limit   int
+}
+
+// NewRecorder starts recording the values received from the
+// channel in, and passing them through to the unbuffered
+// channel returned by Tap. If limit is positive, only the
+// most recent limit values are kept. Otherwise the recording
+// grows without bound for as long as in is open. When in is
+// closed, the output of Tap is closed. The Options also apply
+// to the goroutines started by Playback.
+//
+// If in is not a channel, NewRecorder will panic.
+func NewRecorder(in interface{}, limit int, opts ...Option) *Recorder {
+	inv := assertChanValue(in)
+	r := &Recorder{
+		out:   make(chan interface{}),
+		o:     applyOptions(opts),
+		limit: limit,
+	}
+	o := r.o
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(r.out)
+		for {
+			x, ok := inv.Recv()
+			if !ok {
+				return
+			}
+			o.traceRecv(op)
+			xi := x.Interface()
+			r.add(record{xi, time.Now()})
+			r.out <- xi
+			o.traceSend()
+		}
+	})
+	return r
+}
+
+func (r *Recorder) add(rec record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.limit <= 0 || len(r.records) < r.limit {
+		r.records = append(r.records, rec)
+		return
+	}
+	r.records[r.start] = rec
+	r.start = (r.start + 1) % r.limit
+}
+
+// Tap returns the channel that the recorded values are passed
+// through to. It must be read for recording to continue.
+func (r *Recorder) Tap() <-chan interface{} {
+	return r.out
+}
+
+// Playback replays the values recorded so far on the returned
+// channel, which is closed after the last one. The first value
+// is sent immediately, and each one after waits for the time
+// between it and the previous value when they were recorded,
+// divided by speed. So a speed of 2 plays back twice as fast.
+// Playback will panic if speed is not positive.
+func (r *Recorder) Playback(speed float64) <-chan interface{} {
+	if speed <= 0 {
+		panic("playback speed must be positive")
+	}
+
+	r.mu.Lock()
+	records := make([]record, 0, len(r.records))
+	records = append(records, r.records[r.start:]...)
+	records = append(records, r.records[:r.start]...)
+	r.mu.Unlock()
+
+	out := make(chan interface{})
+	o := r.o
+	o.spawn(func(*operator) {
+		defer o.traceClose()
+		defer close(out)
+		for i, rec := range records {
+			if i > 0 {
+				gap := rec.at.Sub(records[i-1].at)
+				time.Sleep(time.Duration(float64(gap) / speed))
+			}
+			out <- rec.x
+			o.traceSend()
+		}
+	})
+	return out
+}
@@ -0,0 +1,60 @@
+package chops
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	in := make(chan int)
+	go func() {
+		for i := 0; i < 4; i++ {
+			in <- i
+			time.Sleep(20 * time.Millisecond)
+		}
+		close(in)
+	}()
+
+	r := NewRecorder(in, 0)
+	var tapped []interface{}
+	for x := range r.Tap() {
+		tapped = append(tapped, x)
+	}
+	want := []interface{}{0, 1, 2, 3}
+	if !reflect.DeepEqual(tapped, want) {
+		t.Fatalf("Tap() = %v, want %v", tapped, want)
+	}
+
+	start := time.Now()
+	var played []interface{}
+	for x := range r.Playback(2) {
+		played = append(played, x)
+	}
+	if !reflect.DeepEqual(played, want) {
+		t.Errorf("Playback() = %v, want %v", played, want)
+	}
+	// Three 20ms gaps at double speed
+	if d := time.Since(start); d < 25*time.Millisecond || d > 200*time.Millisecond {
+		t.Errorf("Playback() took %v, want about 30ms", d)
+	}
+}
+
+func TestRecorderLimit(t *testing.T) {
+	in := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		in <- i
+	}
+	close(in)
+
+	r := NewRecorder(in, 3)
+	for range r.Tap() {
+	}
+	var played []interface{}
+	for x := range r.Playback(1000) {
+		played = append(played, x)
+	}
+	if want := []interface{}{2, 3, 4}; !reflect.DeepEqual(played, want) {
+		t.Errorf("Playback() = %v, want %v", played, want)
+	}
+}
@@ -0,0 +1,187 @@
+package chops
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
+// OperatorInfo describes a goroutine that an operator is
+// running. See ActiveOperators.
+type OperatorInfo struct {
+	// Name is the name of the function that started the
+	// goroutine, such as "MapG" or "Recorder.Playback".
+	Name string
+	// Label is the stage name given to the operator with
+	// WithLabel or WithTracer. It is empty for operators that
+	// weren't given one, including all the operators that
+	// don't accept Options.
+	Label string
+	// Created is when the goroutine was started.
+	Created time.Time
+	// Processed is the number of values the goroutine has
+	// received so far. It is counted by every operator that
+	// accepts Options (see Option), in the goroutines that
+	// receive from the operator's inputs. Their other
+	// goroutines, such as the one that closes MergeG's output,
+	// and the operators that take no Options, report 0.
+	Processed int64
+}
+
+// operator is a registry entry. A nil *operator is valid and
+// does nothing, which is what spawnOp hands out while tracking
+// is disabled.
+type operator struct {
+	name      string
+	label     string
+	created   time.Time
+	processed int64
+}
+
+func (op *operator) count() {
+	if op != nil {
+		atomic.AddInt64(&op.processed, 1)
+	}
+}
+
+var registry struct {
+	enabled int32
+	mu      sync.Mutex
+	ops     map[*operator]struct{}
+}
+
+// EnableTracking turns the registry of running operators
+// behind ActiveOperators on or off. It is off by default, so
+// operators pay nothing for it unless it is needed, for
+// example by a debugging endpoint. Only operators started
+// while tracking is on are registered, and turning it off
+// forgets every registered operator.
+func EnableTracking(on bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if on {
+		atomic.StoreInt32(&registry.enabled, 1)
+		if registry.ops == nil {
+			registry.ops = make(map[*operator]struct{})
+		}
+	} else {
+		atomic.StoreInt32(&registry.enabled, 0)
+		registry.ops = nil
+	}
+}
+
+// ActiveOperators returns a snapshot of the goroutines that
+// operators are currently running, oldest first. Operators
+// that run several goroutines, such as MergeG, appear once for
+// each of them. A goroutine disappears from the registry when
+// it exits. If tracking is off, ActiveOperators returns nil.
+func ActiveOperators() []OperatorInfo {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	var infos []OperatorInfo
+	for op := range registry.ops {
+		infos = append(infos, OperatorInfo{
+			Name:      op.name,
+			Label:     op.label,
+			Created:   op.created,
+			Processed: atomic.LoadInt64(&op.processed),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Created.Before(infos[j].Created)
+	})
+	return infos
+}
+
+// register adds an entry for a goroutine that is about to be
+// started on behalf of the calling operator, or returns nil if
+// tracking is off.
+func register(label string) *operator {
+	if atomic.LoadInt32(&registry.enabled) == 0 {
+		return nil
+	}
+	op := &operator{
+		name:    operatorName(),
+		label:   label,
+		created: time.Now(),
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.ops != nil {
+		registry.ops[op] = struct{}{}
+	}
+	return op
+}
+
+func (op *operator) deregister() {
+	if op == nil {
+		return
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	delete(registry.ops, op)
+}
+
+var pkgPrefix = reflect.TypeOf(operator{}).PkgPath() + "."
+
+// operatorName finds the innermost exported function of this
+// package on the caller's stack, which is the operator that
+// is starting a goroutine. The unexported helpers in between,
+// like spawn, are skipped.
+func operatorName() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		name := strings.TrimPrefix(frame.Function, pkgPrefix)
+		if name == frame.Function || strings.Contains(name, "/") {
+			// Left the package without finding an operator
+			break
+		}
+		if name, ok := exportedName(name); ok {
+			return name
+		}
+		if !more {
+			break
+		}
+	}
+	return "unknown"
+}
+
+// exportedName turns a function name as reported by the
+// runtime, without its package, into its name in source, and
+// reports whether it is exported. For example,
+// "MapG[...].func1" becomes "MapG", and
+// "(*Recorder).Playback.func2" becomes "Recorder.Playback".
+func exportedName(fn string) (string, bool) {
+	if !strings.HasPrefix(fn, "(") {
+		name := cutIdent(fn)
+		return name, isExported(name)
+	}
+	i := strings.Index(fn, ").")
+	if i < 0 {
+		return "", false
+	}
+	recv := cutIdent(strings.TrimLeft(fn[:i], "(*"))
+	method := cutIdent(fn[i+2:])
+	return recv + "." + method, isExported(recv) && isExported(method)
+}
+
+func cutIdent(s string) string {
+	if i := strings.IndexAny(s, ".["); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func isExported(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
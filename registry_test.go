@@ -0,0 +1,171 @@
+package chops
+
+import (
+	"context"
+	"testing"
+)
+
+// operatorsNamed returns the registered operators called
+// name, ignoring any that other tests left running.
+func operatorsNamed(name string) []OperatorInfo {
+	var ops []OperatorInfo
+	for _, op := range ActiveOperators() {
+		if op.Name == name {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+func TestActiveOperators(t *testing.T) {
+	if got := ActiveOperators(); got != nil {
+		t.Fatalf("ActiveOperators() = %v with tracking off", got)
+	}
+	EnableTracking(true)
+	defer EnableTracking(false)
+
+	in := make(chan int)
+	out := MapG(context.Background(), in, func(x int) int { return x })
+	if ops := operatorsNamed("MapG"); len(ops) != 1 {
+		t.Fatalf("ActiveOperators() has %d MapG, want 1", len(ops))
+	}
+
+	for i := 0; i < 2; i++ {
+		in <- i
+		<-out
+	}
+	if p := operatorsNamed("MapG")[0].Processed; p != 2 {
+		t.Errorf("Processed = %d, want 2", p)
+	}
+
+	close(in)
+	eventually(t, "MapG to deregister", func() bool {
+		return len(operatorsNamed("MapG")) == 0
+	})
+}
+
+func TestActiveOperatorsNames(t *testing.T) {
+	EnableTracking(true)
+	defer EnableTracking(false)
+
+	ch1, ch2 := make(chan int), make(chan int)
+	MergeG(context.Background(), []<-chan int{ch1, ch2})
+	lazyIn := make(chan int)
+	MakeLazyBroadcaster(lazyIn, 0)
+	teeIn := make(chan int)
+	TeeSynced(0, teeIn, WithLabel("ingest"))
+
+	counts := make(map[string]int)
+	labels := make(map[string]string)
+	for _, op := range ActiveOperators() {
+		counts[op.Name]++
+		if op.Label != "" {
+			labels[op.Name] = op.Label
+		}
+	}
+	// One goroutine per input, plus one to close the output
+	if counts["MergeG"] != 3 {
+		t.Errorf("%d MergeG goroutines registered, want 3", counts["MergeG"])
+	}
+	if counts["MakeLazyBroadcaster"] != 1 {
+		t.Errorf("%d MakeLazyBroadcaster goroutines registered, want 1",
+			counts["MakeLazyBroadcaster"])
+	}
+	if labels["TeeSynced"] != "ingest" {
+		t.Errorf("labels = %v, want the stage name", labels)
+	}
+
+	close(ch1)
+	close(ch2)
+	close(teeIn)
+	eventually(t, "MergeG to deregister", func() bool {
+		return len(operatorsNamed("MergeG")) == 0 &&
+			len(operatorsNamed("TeeSynced")) == 0
+	})
+	close(lazyIn)
+
+	EnableTracking(false)
+	if got := ActiveOperators(); got != nil {
+		t.Errorf("ActiveOperators() = %v after disabling", got)
+	}
+}
+
+func TestActiveOperatorsProcessed(t *testing.T) {
+	EnableTracking(true)
+	defer EnableTracking(false)
+
+	// Operators without a type parameter count values too
+	in := make(chan int)
+	out := MakeFanInSummary(0, 0, func(acc, v interface{}) interface{} {
+		return acc.(int) + v.(int)
+	}, in, WithLabel("sum"))
+	for i := 0; i < 3; i++ {
+		in <- i
+		<-out
+	}
+	ops := operatorsNamed("MakeFanInSummary")
+	if len(ops) != 1 {
+		t.Fatalf("ActiveOperators() has %d MakeFanInSummary, want 1", len(ops))
+	}
+	if ops[0].Label != "sum" || ops[0].Processed != 3 {
+		t.Errorf("Label, Processed = %q, %d, want \"sum\", 3", ops[0].Label, ops[0].Processed)
+	}
+
+	close(in)
+	for range out {
+	}
+	eventually(t, "MakeFanInSummary to deregister", func() bool {
+		return len(operatorsNamed("MakeFanInSummary")) == 0
+	})
+}
+
+func TestExportedName(t *testing.T) {
+	tests := []struct {
+		fn       string
+		want     string
+		exported bool
+	}{
+		{"MapG[...]", "MapG", true},
+		{"MakeGroupByOrdered[...].func1", "MakeGroupByOrdered", true},
+		{"(*Recorder).Playback.func2", "Recorder.Playback", true},
+		{"(*options).spawn", "options.spawn", false},
+		{"spawnOp", "spawnOp", false},
+	}
+	for _, tt := range tests {
+		got, exported := exportedName(tt.fn)
+		if got != tt.want || exported != tt.exported {
+			t.Errorf("exportedName(%q) = %q, %v, want %q, %v",
+				tt.fn, got, exported, tt.want, tt.exported)
+		}
+	}
+}
+
+func TestActiveOperatorsProcessedMerge(t *testing.T) {
+	EnableTracking(true)
+	defer EnableTracking(false)
+
+	// Left open, so the goroutines are still registered
+	ch1, ch2 := make(chan int, 2), make(chan int, 1)
+	ch1 <- 1
+	ch1 <- 2
+	ch2 <- 3
+	out := MergeG(context.Background(), []<-chan int{ch1, ch2})
+	for i := 0; i < 3; i++ {
+		<-out
+	}
+
+	// Spread over the goroutine for each input
+	var total int64
+	for _, op := range operatorsNamed("MergeG") {
+		total += op.Processed
+	}
+	if total != 3 {
+		t.Errorf("Processed adds up to %d, want 3", total)
+	}
+
+	close(ch1)
+	close(ch2)
+	eventually(t, "MergeG to deregister", func() bool {
+		return len(operatorsNamed("MergeG")) == 0
+	})
+}
@@ -0,0 +1,83 @@
+package chops
+
+import (
+	"errors"
+	"strings"
+)
+
+// Result carries either a value or the error that prevented it
+// from being produced, for pipelines where a stage can fail
+// per element.
+type Result struct {
+	Value interface{}
+	Err   error
+}
+
+// ResultG is the typed counterpart of Result.
+type ResultG[T any] struct {
+	Value T
+	Err   error
+}
+
+// CollectErrors receives from in until it is closed, and
+// separates the values of the successful Results from the
+// errors of the failed ones. Both slices keep the order in
+// which the Results were received.
+func CollectErrors(in <-chan Result) (values []interface{}, errs []error) {
+	for r := range in {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		} else {
+			values = append(values, r.Value)
+		}
+	}
+	return
+}
+
+// CollectErr is like CollectErrors, but combines the errors
+// into one, which is nil if there were none. The combined
+// error's message has one line per error, and errors.Is and
+// errors.As match it against each of the errors in turn.
+func CollectErr(in <-chan Result) ([]interface{}, error) {
+	values, errs := CollectErrors(in)
+	if len(errs) == 0 {
+		return values, nil
+	}
+	return values, joinedError(errs)
+}
+
+type joinedError []error
+
+func (e joinedError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Is and As let errors.Is and errors.As look inside e. From
+// Go 1.20 they follow Unwrap() []error by themselves, but the
+// older releases that this module supports don't.
+
+func (e joinedError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e joinedError) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e joinedError) Unwrap() []error {
+	return e
+}
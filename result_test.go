@@ -0,0 +1,69 @@
+package chops
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+func results(rs ...Result) <-chan Result {
+	ch := make(chan Result, len(rs))
+	for _, r := range rs {
+		ch <- r
+	}
+	close(ch)
+	return ch
+}
+
+func TestCollectErrors(t *testing.T) {
+	err1, err2 := errors.New("oof"), errors.New("yeet")
+	values, errs := CollectErrors(results(
+		Result{Value: 1},
+		Result{Err: err1},
+		Result{Value: 2},
+		Result{Err: err2},
+		Result{Value: 3},
+	))
+	if want := []interface{}{1, 2, 3}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+	if want := []error{err1, err2}; !reflect.DeepEqual(errs, want) {
+		t.Errorf("errs = %v, want %v", errs, want)
+	}
+}
+
+func TestCollectErr(t *testing.T) {
+	err1, err2 := errors.New("oof"), errors.New("yeet")
+	values, err := CollectErr(results(
+		Result{Value: 1},
+		Result{Err: err1},
+		Result{Err: err2},
+	))
+	if want := []interface{}{1}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+	if err == nil || err.Error() != "oof\nyeet" {
+		t.Fatalf("err = %v, want oof\\nyeet", err)
+	}
+	if !errors.Is(err, err2) {
+		t.Error("errors.Is(err, err2) = false, want true")
+	}
+	// errors.Is only follows Unwrap() []error from Go 1.20, so
+	// check that the combined error matches on its own
+	if !err.(interface{ Is(error) bool }).Is(err2) {
+		t.Error("err.Is(err2) = false, want true")
+	}
+	var pathErr *fs.PathError
+	_, err = CollectErr(results(
+		Result{Err: err1},
+		Result{Err: &fs.PathError{Op: "open", Path: "x", Err: err2}},
+	))
+	if !err.(interface{ As(interface{}) bool }).As(&pathErr) || pathErr.Path != "x" {
+		t.Errorf("err.As(*fs.PathError) = %v, want the second error", pathErr)
+	}
+
+	if _, err := CollectErr(results(Result{Value: 1})); err != nil {
+		t.Errorf("err = %v with no failures, want nil", err)
+	}
+}
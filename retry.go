@@ -0,0 +1,101 @@
+package chops
+
+import (
+	"context"
+	"time"
+)
+
+// MakeMapRetry calls f on every value received from the
+// channel in, and sends the outcome on the returned channel,
+// which has capacity outCap, as a Result. If f fails, it is
+// retried up to maxRetries times, so it is called at most
+// maxRetries+1 times per value. Before retry number attempt,
+// counting from 1, MakeMapRetry sleeps for backoff(attempt),
+// or not at all if backoff is nil. The Result holds the value
+// from the first successful call, or the error from the last
+// call if every call failed. Values are processed one at a
+// time, in order. When in is closed, the output is closed.
+//
+// If in is not a channel, MakeMapRetry will panic.
+func MakeMapRetry(outCap, maxRetries int, backoff func(attempt int) time.Duration,
+	f func(interface{}) (interface{}, error), in interface{}, opts ...Option) chan Result {
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	out := make(chan Result, outCap)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		for {
+			x, ok := inv.Recv()
+			if !ok {
+				return
+			}
+			o.traceRecv(op)
+			y, err := retry(context.Background(), maxRetries, backoff,
+				func() (interface{}, error) { return f(x.Interface()) })
+			out <- Result{y, err}
+			o.traceSend()
+		}
+	})
+	return out
+}
+
+// MapRetryG is like MakeMapRetry, but typed, and follows the
+// lifecycle convention of the generic stages. If ctx is done
+// while waiting to retry, the stage stops without sending a
+// Result for the value.
+func MapRetryG[T, U any](ctx context.Context, maxRetries int,
+	backoff func(attempt int) time.Duration, f func(T) (U, error),
+	in <-chan T, opts ...Option) <-chan ResultG[U] {
+	o := applyOptions(opts)
+	out := make(chan ResultG[U])
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		for {
+			select {
+			case x, ok := <-in:
+				if !ok {
+					return
+				}
+				o.traceRecv(op)
+				y, err := retry(ctx, maxRetries, backoff,
+					func() (U, error) { return f(x) })
+				if ctx.Err() != nil {
+					return
+				}
+				select {
+				case out <- ResultG[U]{y, err}:
+					o.traceSend()
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// retry calls f until it succeeds or has been retried
+// maxRetries times, waiting backoff(attempt) before each
+// retry, and returns the outcome of the last call. It gives
+// up early if ctx is done while waiting.
+func retry[T any](ctx context.Context, maxRetries int,
+	backoff func(attempt int) time.Duration, f func() (T, error)) (T, error) {
+	y, err := f()
+	for attempt := 1; err != nil && attempt <= maxRetries; attempt++ {
+		if backoff != nil {
+			timer := time.NewTimer(backoff(attempt))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return y, err
+			}
+		}
+		y, err = f()
+	}
+	return y, err
+}
@@ -0,0 +1,76 @@
+package chops
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var errFlaky = errors.New("flaky")
+
+func TestMakeMapRetry(t *testing.T) {
+	var backoffs []int
+	backoff := func(attempt int) time.Duration {
+		backoffs = append(backoffs, attempt)
+		return time.Millisecond
+	}
+	calls := make(map[interface{}]int)
+	f := func(x interface{}) (interface{}, error) {
+		calls[x]++
+		// "ok" succeeds on its third call
+		if x == "ok" && calls[x] == 3 {
+			return "done", nil
+		}
+		return nil, errFlaky
+	}
+
+	in := make(chan string, 2)
+	in <- "ok"
+	in <- "never"
+	close(in)
+	got := collect(MakeMapRetry(0, 4, backoff, f, in))
+
+	want := []Result{{"done", nil}, {nil, errFlaky}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MakeMapRetry() = %v, want %v", got, want)
+	}
+	if calls["ok"] != 3 || calls["never"] != 5 {
+		t.Errorf("calls = %v, want 3 and maxRetries+1", calls)
+	}
+	if want := []int{1, 2, 1, 2, 3, 4}; !reflect.DeepEqual(backoffs, want) {
+		t.Errorf("backoff attempts = %v, want %v", backoffs, want)
+	}
+}
+
+func TestMapRetryG(t *testing.T) {
+	calls := 0
+	f := func(x int) (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, errFlaky
+		}
+		return x * 10, nil
+	}
+	got := collect(MapRetryG(context.Background(), 2, nil, f, source(1, 2)))
+	want := []ResultG[int]{{10, nil}, {20, nil}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MapRetryG() = %v, want %v", got, want)
+	}
+}
+
+func TestMapRetryGCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := func(int) (int, error) { return 0, errFlaky }
+	out := MapRetryG(ctx, 10, func(int) time.Duration { return time.Hour }, f, source(1))
+	cancel()
+	select {
+	case r, ok := <-out:
+		if ok {
+			t.Errorf("received %v after cancel", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("output not closed after cancel")
+	}
+}
@@ -0,0 +1,79 @@
+package chops
+
+import "context"
+
+// Route sends each value received from in to exactly one
+// output: the matched channel at the index of the first route
+// whose predicate returns true, in the order the routes are
+// given, or unmatched if none do. Later predicates are not
+// called for a value once one has matched. All the outputs are
+// closed when in is closed or ctx is done.
+//
+// The outputs are unbuffered and fed by a single goroutine,
+// so every output must be read concurrently; an output that
+// isn't read holds up all the others.
+//
+// The routes are given as a slice, so that Options can follow
+// them.
+func Route[T any](ctx context.Context, in <-chan T, routes []func(T) bool,
+	opts ...Option) (matched []<-chan T, unmatched <-chan T) {
+	o := applyOptions(opts)
+	outs := make([]chan T, len(routes))
+	matched = make([]<-chan T, len(routes))
+	for i := range outs {
+		outs[i] = make(chan T)
+		matched[i] = outs[i]
+	}
+	none := make(chan T)
+
+	o.spawn(func(op *operator) {
+		defer func() {
+			for _, ch := range outs {
+				close(ch)
+			}
+			close(none)
+			o.traceClose()
+		}()
+		for {
+			select {
+			case x, ok := <-in:
+				if !ok {
+					return
+				}
+				o.traceRecv(op)
+				dst := none
+				for i, route := range routes {
+					if route(x) {
+						dst = outs[i]
+						break
+					}
+				}
+				select {
+				case dst <- x:
+					o.traceSend()
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return matched, none
+}
+
+// PartitionG splits in into two outputs: yes receives the
+// values for which pred returns true, and no receives the
+// rest. pred is called exactly once per value, in the order
+// the values are received, and each output keeps that order.
+// Both outputs are closed when in is closed or ctx is done.
+//
+// Like Route, the outputs are unbuffered and fed by a single
+// goroutine, so a slow consumer of one output applies
+// backpressure to in, and so also holds up the other output.
+func PartitionG[T any](ctx context.Context, in <-chan T, pred func(T) bool,
+	opts ...Option) (yes, no <-chan T) {
+	matched, unmatched := Route(ctx, in, []func(T) bool{pred}, opts...)
+	return matched[0], unmatched
+}
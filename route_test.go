@@ -0,0 +1,84 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRoute(t *testing.T) {
+	in := source(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	matched, unmatched := Route(context.Background(), in, []func(int) bool{
+		func(x int) bool { return x%2 == 0 },
+		func(x int) bool { return x%3 == 0 },
+	})
+
+	// 6 matches both routes, but only goes to the first
+	want := [][]int{{2, 4, 6, 8, 10}, {3, 9}, {1, 5, 7}}
+	got := make([][]int, 3)
+	var wg sync.WaitGroup
+	for i, ch := range append(matched, unmatched) {
+		wg.Add(1)
+		go func(i int, ch <-chan int) {
+			defer wg.Done()
+			got[i] = collect(ch)
+		}(i, ch)
+	}
+	wg.Wait()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Route() = %v, want %v", got, want)
+	}
+}
+
+func TestPartitionG(t *testing.T) {
+	calls := 0
+	even := func(x int) bool {
+		calls++
+		return x%2 == 0
+	}
+	yes, no := PartitionG(context.Background(), source(1, 2, 3, 4, 5, 6, 7), even)
+	var gotYes []int
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		gotYes = collect(yes)
+	}()
+	gotNo := collect(no)
+	<-done
+
+	if want := []int{2, 4, 6}; !reflect.DeepEqual(gotYes, want) {
+		t.Errorf("yes = %v, want %v", gotYes, want)
+	}
+	if want := []int{1, 3, 5, 7}; !reflect.DeepEqual(gotNo, want) {
+		t.Errorf("no = %v, want %v", gotNo, want)
+	}
+	if calls != 7 {
+		t.Errorf("pred called %d times, want 7", calls)
+	}
+}
+
+func TestPartitionGBackpressure(t *testing.T) {
+	in := make(chan int)
+	yes, no := PartitionG(context.Background(), in, func(x int) bool { return x > 0 })
+
+	// Nobody reads yes, so once a value is stuck there, in
+	// stops being received from and no gets nothing
+	in <- 1
+	select {
+	case in <- -1:
+		t.Fatal("in not held up by an unread output")
+	case x := <-no:
+		t.Fatalf("no received %d while yes was stuck", x)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	<-yes
+	in <- -1
+	if x := <-no; x != -1 {
+		t.Errorf("no received %d, want -1", x)
+	}
+	close(in)
+}
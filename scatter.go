@@ -0,0 +1,173 @@
+package chops
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ScatterGather calls f on the values received from in using
+// k workers running concurrently, and sends the results on a
+// single output. Each worker takes the next value from in as
+// soon as it is free, so slow calls to f don't hold up the
+// other workers. Results are sent in the order they complete,
+// which may differ from the order of the inputs; use
+// ScatterGatherOrdered to keep it. The output is closed once
+// in is closed and every worker has finished, or promptly
+// when ctx is done.
+//
+// ScatterGather will panic if k is not positive.
+func ScatterGather[T, U any](ctx context.Context, k int, f func(T) U, in <-chan T,
+	opts ...Option) <-chan U {
+	if k <= 0 {
+		panic(fmt.Sprintf("invalid worker count %d", k))
+	}
+	o := applyOptions(opts)
+	out := make(chan U)
+	// k workers, plus one to close the output
+	g := o.group(k + 1)
+	var wg sync.WaitGroup
+	wg.Add(k)
+	for i := 0; i < k; i++ {
+		o.spawnIn(g, func(op *operator) {
+			defer wg.Done()
+			for {
+				select {
+				case x, ok := <-in:
+					if !ok {
+						return
+					}
+					o.traceRecv(op)
+					select {
+					case out <- f(x):
+						o.traceSend()
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+	}
+	o.spawnIn(g, func(*operator) {
+		wg.Wait()
+		close(out)
+		o.traceClose()
+	})
+	return out
+}
+
+// ScatterGatherOrdered is like ScatterGather, but sends the
+// results in the same order as the values they were computed
+// from. Values are numbered as they are received from in, and
+// results that complete out of turn wait in a reorder buffer
+// until the results before them have been sent. No more than k
+// values are taken from in ahead of the oldest result not yet
+// sent, so at most k calls to f run at once, and a slow call
+// to f holds up the results of later values and, once k values
+// are waiting on it, the workers too.
+//
+// ScatterGatherOrdered will panic if k is not positive.
+func ScatterGatherOrdered[T, U any](ctx context.Context, k int, f func(T) U, in <-chan T,
+	opts ...Option) <-chan U {
+	if k <= 0 {
+		panic(fmt.Sprintf("invalid worker count %d", k))
+	}
+	o := applyOptions(opts)
+	type job struct {
+		seq int
+		x   T
+	}
+	type result struct {
+		seq int
+		y   U
+	}
+	out := make(chan U)
+	jobs := make(chan job)
+	results := make(chan result)
+	// A slot is taken for each value received from in, and
+	// given back when its result is sent
+	slots := make(chan struct{}, k)
+
+	// A dispatcher, k workers and a gatherer
+	g := o.group(k + 2)
+	o.spawnIn(g, func(op *operator) {
+		defer close(jobs)
+		for seq := 0; ; seq++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case x, ok := <-in:
+				if !ok {
+					return
+				}
+				o.traceRecv(op)
+				select {
+				case jobs <- job{seq, x}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	live := int32(k)
+	for i := 0; i < k; i++ {
+		o.spawnIn(g, func(*operator) {
+			defer func() {
+				if atomic.AddInt32(&live, -1) == 0 {
+					close(results)
+				}
+			}()
+			for j := range jobs {
+				select {
+				case results <- result{j.seq, f(j.x)}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+	}
+
+	o.spawnIn(g, func(*operator) {
+		defer o.traceClose()
+		defer close(out)
+		pending := make(map[int]U, k)
+		next := 0
+		for {
+			select {
+			case r, ok := <-results:
+				if !ok {
+					return
+				}
+				pending[r.seq] = r.y
+			case <-ctx.Done():
+				return
+			}
+			for {
+				y, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				select {
+				case out <- y:
+					o.traceSend()
+				case <-ctx.Done():
+					return
+				}
+				next++
+				<-slots
+			}
+		}
+	})
+
+	return out
+}
@@ -0,0 +1,104 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScatterGather(t *testing.T) {
+	const n, k = 100, 4
+	xs := make([]int, n)
+	for i := range xs {
+		xs[i] = i
+	}
+
+	var running, peak int32
+	square := func(x int) int {
+		r := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if r <= p || atomic.CompareAndSwapInt32(&peak, p, r) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return x * x
+	}
+
+	got := collect(ScatterGather(context.Background(), k, square, source(xs...)))
+	if len(got) != n {
+		t.Fatalf("received %d results, want %d", len(got), n)
+	}
+	sort.Ints(got)
+	for i, y := range got {
+		if y != i*i {
+			t.Fatalf("results = %v, want squares of 0..%d", got, n-1)
+		}
+	}
+	if p := atomic.LoadInt32(&peak); p < 2 || p > k {
+		t.Errorf("%d calls ran at once, want between 2 and %d", p, k)
+	}
+}
+
+func TestScatterGatherOrdered(t *testing.T) {
+	// Earlier values take longer, so they would finish last
+	// if the order weren't restored
+	slow := func(x int) int {
+		time.Sleep(time.Duration(5-x) * time.Millisecond)
+		return x * 10
+	}
+	got := collect(ScatterGatherOrdered(context.Background(), 3, slow, source(0, 1, 2, 3, 4)))
+	if want := []int{0, 10, 20, 30, 40}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ScatterGatherOrdered() = %v, want %v", got, want)
+	}
+}
+
+func TestScatterGatherOrderedBound(t *testing.T) {
+	const k = 3
+	release := make(chan struct{})
+	var calls int32
+	f := func(x int) int {
+		atomic.AddInt32(&calls, 1)
+		if x == 0 {
+			<-release
+		}
+		return x
+	}
+	out := ScatterGatherOrdered(context.Background(), k, f, source(0, 1, 2, 3, 4, 5))
+
+	// While the first call is stuck, only k values may be
+	// taken from in, so the later ones don't start
+	eventually(t, "k calls", func() bool { return atomic.LoadInt32(&calls) == k })
+	time.Sleep(20 * time.Millisecond)
+	if c := atomic.LoadInt32(&calls); c != k {
+		t.Fatalf("%d calls started behind a stuck one, want %d", c, k)
+	}
+
+	close(release)
+	if got, want := collect(out), []int{0, 1, 2, 3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ScatterGatherOrdered() = %v, want %v", got, want)
+	}
+}
+
+func TestScatterGatherCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out := ScatterGather(ctx, 2, func(x int) int { return x }, in)
+	ordered := ScatterGatherOrdered(ctx, 2, func(x int) int { return x }, in)
+	cancel()
+	for _, ch := range []<-chan int{out, ordered} {
+		select {
+		case _, ok := <-ch:
+			if ok {
+				t.Error("received a value after cancel")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("output not closed after cancel")
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package chops
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// Select2 blocks until either chA or chB is ready to
+// receive, then calls the matching handler with the
+// received value and an ok flag with the same meaning as
+// in `x, ok := <-ch`. If both are ready, one is chosen at
+// random. It compiles down to a native select statement, so
+// unlike reflect.Select, no reflection or boxing is
+// involved. As with a native select, a nil channel is never
+// ready.
+func Select2[A, B any](
+	chA <-chan A, handleA func(A, bool),
+	chB <-chan B, handleB func(B, bool),
+) {
+	select {
+	case a, ok := <-chA:
+		handleA(a, ok)
+	case b, ok := <-chB:
+		handleB(b, ok)
+	}
+}
+
+// Select3 is like Select2, but for three channels.
+func Select3[A, B, C any](
+	chA <-chan A, handleA func(A, bool),
+	chB <-chan B, handleB func(B, bool),
+	chC <-chan C, handleC func(C, bool),
+) {
+	select {
+	case a, ok := <-chA:
+		handleA(a, ok)
+	case b, ok := <-chB:
+		handleB(b, ok)
+	case c, ok := <-chC:
+		handleC(c, ok)
+	}
+}
+
+// selectFunc is what the fan-in, fan-out and merge operators
+// call instead of reflect.Select, so that tests can make
+// their choices deterministic. It holds a
+// func([]reflect.SelectCase) (int, reflect.Value, bool).
+var selectFunc atomic.Value
+
+func init() {
+	selectFunc.Store(reflect.Select)
+}
+
+// doSelect calls the current selectFunc.
+func doSelect(cases []reflect.SelectCase) (int, reflect.Value, bool) {
+	f := selectFunc.Load().(func([]reflect.SelectCase) (int, reflect.Value, bool))
+	return f(cases)
+}
+
+// SetSelectFunc replaces reflect.Select in the fan-in,
+// fan-out and merge operators with f, which must behave like
+// reflect.Select apart from how it chooses among ready cases.
+// reflect.Select chooses at random, so tests can use this to
+// make an operator's output order reproducible, for example by
+// always choosing the lowest ready case. A nil f restores
+// reflect.Select.
+//
+// SetSelectFunc is for tests only. It affects every operator
+// in the process, including ones that are already running.
+func SetSelectFunc(f func([]reflect.SelectCase) (int, reflect.Value, bool)) {
+	if f == nil {
+		f = reflect.Select
+	}
+	selectFunc.Store(f)
+}
@@ -0,0 +1,109 @@
+package chops
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelect2(t *testing.T) {
+	chA := make(chan int, 1)
+	chB := make(chan string)
+	close(chB)
+	chA <- 1
+
+	var gotA, gotB int
+	handleA := func(x int, ok bool) {
+		if !ok || x != 1 {
+			t.Errorf("handleA(%v, %v), want (1, true)", x, ok)
+		}
+		gotA++
+	}
+	handleB := func(x string, ok bool) {
+		if ok || x != "" {
+			t.Errorf("handleB(%q, %v), want (\"\", false)", x, ok)
+		}
+		gotB++
+	}
+
+	// chB is always ready since it is closed, but chA only
+	// has one value
+	for gotA == 0 {
+		Select2(chA, handleA, chB, handleB)
+	}
+	Select2(chA, handleA, chB, handleB)
+	if gotA != 1 || gotB == 0 {
+		t.Errorf("handleA ran %d times, handleB ran %d times", gotA, gotB)
+	}
+}
+
+func TestSelect3(t *testing.T) {
+	chA := make(chan int)
+	chB := make(chan string)
+	chC := make(chan struct{}, 1)
+	chC <- struct{}{}
+
+	fail := func(name string) func(interface{}, bool) {
+		return func(interface{}, bool) {
+			t.Errorf("%s should not be ready", name)
+		}
+	}
+	ran := false
+	Select3(
+		chA, func(x int, ok bool) { fail("chA")(x, ok) },
+		chB, func(x string, ok bool) { fail("chB")(x, ok) },
+		chC, func(_ struct{}, ok bool) {
+			if !ok {
+				t.Error("chC should not be closed")
+			}
+			ran = true
+		},
+	)
+	if !ran {
+		t.Error("handleC did not run")
+	}
+}
+
+// lowestReady is a deterministic reflect.Select that always
+// chooses the ready case with the lowest index.
+func lowestReady(cases []reflect.SelectCase) (int, reflect.Value, bool) {
+	probe := []reflect.SelectCase{{}, {Dir: reflect.SelectDefault}}
+	for i, c := range cases {
+		if !c.Chan.IsValid() {
+			continue
+		}
+		probe[0] = c
+		if j, x, ok := reflect.Select(probe); j == 0 {
+			return i, x, ok
+		}
+	}
+	return reflect.Select(cases)
+}
+
+func TestSetSelectFunc(t *testing.T) {
+	SetSelectFunc(lowestReady)
+	defer SetSelectFunc(nil)
+
+	fill := func() []interface{} {
+		chs := make([]interface{}, 3)
+		for i := range chs {
+			ch := make(chan int, 3)
+			for j := 0; j < 3; j++ {
+				ch <- i*10 + j
+			}
+			close(ch)
+			chs[i] = ch
+		}
+		return chs
+	}
+
+	// Every input is ready from the start, so the lowest
+	// index is drained first, every time
+	want := []interface{}{0, 1, 2, 10, 11, 12, 20, 21, 22}
+	for run := 0; run < 5; run++ {
+		var stats FanStats
+		got := collect(MakeFanInStats(0, &stats, fill()...))
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d: received %v, want %v", run, got, want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package chops
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Selector receives from a dynamic set of channels without
+// spawning any goroutines. Each call to Recv performs a
+// single select over the current set in the caller's
+// goroutine, which suits event loops that must own their own
+// scheduling. The zero value is an empty Selector ready to
+// use. A Selector must not be used concurrently.
+//
+// The select cases are kept between calls and only change
+// when the set does, so once the set is stable, Recv doesn't
+// rebuild them. This saves one allocation per call, of a size
+// proportional to the set, but Recv is not allocation-free:
+// reflect.Select allocates on every call, as
+// BenchmarkSelectorRecv shows next to
+// BenchmarkSelectorRecvRebuild.
+type Selector struct {
+	cases []reflect.SelectCase
+}
+
+// NewSelector returns an empty Selector.
+func NewSelector() *Selector {
+	return &Selector{}
+}
+
+// Add adds ch to the set. If ch is not a channel that can be
+// received from, Add will panic.
+func (s *Selector) Add(ch interface{}) {
+	v := assertChanValue(ch)
+	if v.Type().ChanDir()&reflect.RecvDir == 0 {
+		panic(fmt.Sprintf("cannot receive from %T", ch))
+	}
+	s.cases = append(s.cases, reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: v,
+	})
+}
+
+// Remove removes ch from the set, returning true if it was
+// present. The channels after it move up by one index.
+func (s *Selector) Remove(ch interface{}) bool {
+	for i, c := range s.cases {
+		if c.Chan.Interface() == ch {
+			s.removeAt(i)
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of channels in the set.
+func (s *Selector) Len() int {
+	return len(s.cases)
+}
+
+func (s *Selector) removeAt(i int) {
+	// Compact in place rather than leaving a hole, so the
+	// remaining cases keep their order without reallocating
+	copy(s.cases[i:], s.cases[i+1:])
+	s.cases[len(s.cases)-1] = reflect.SelectCase{}
+	s.cases = s.cases[:len(s.cases)-1]
+}
+
+// Recv blocks until any channel in the set can be received
+// from, and returns its index in the set (in the order the
+// channels were added) along with the result of the receive.
+// If the Status is Ok, x is the received value. If the Status
+// is Closed, x is the zero value of the channel's element
+// type, and the channel has been removed from the set. If the
+// set is empty, Recv returns -1, nil and Closed immediately.
+func (s *Selector) Recv() (idx int, x interface{}, stat Status) {
+	if len(s.cases) == 0 {
+		return -1, nil, Closed
+	}
+
+	i, xv, ok := doSelect(s.cases)
+	if !ok {
+		s.removeAt(i)
+		return i, xv.Interface(), Closed
+	}
+	return i, xv.Interface(), Ok
+}
@@ -0,0 +1,97 @@
+package chops
+
+import "testing"
+
+func TestSelector(t *testing.T) {
+	a := make(chan int, 1)
+	b := make(chan string, 1)
+	c := make(chan struct{})
+
+	s := NewSelector()
+	s.Add(a)
+	s.Add(b)
+	s.Add(c)
+	if s.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", s.Len())
+	}
+
+	a <- 1
+	idx, x, stat := s.Recv()
+	if idx != 0 || x != 1 || stat != Ok {
+		t.Errorf("Recv() = (%d, %v, %v), want (0, 1, Ok)", idx, x, stat)
+	}
+
+	close(b)
+	idx, x, stat = s.Recv()
+	if idx != 1 || x != "" || stat != Closed {
+		t.Errorf("Recv() = (%d, %v, %v), want (1, \"\", Closed)", idx, x, stat)
+	}
+	if s.Len() != 2 {
+		t.Errorf("Len() = %d after close, want 2", s.Len())
+	}
+
+	if !s.Remove(a) {
+		t.Error("Remove(a) = false, want true")
+	}
+	if s.Remove(a) {
+		t.Error("Remove(a) = true after removal, want false")
+	}
+
+	// c is now at index 0
+	go func() { c <- struct{}{} }()
+	idx, _, stat = s.Recv()
+	if idx != 0 || stat != Ok {
+		t.Errorf("Recv() = (%d, _, %v), want (0, _, Ok)", idx, stat)
+	}
+
+	s.Remove(c)
+	idx, x, stat = s.Recv()
+	if idx != -1 || x != nil || stat != Closed {
+		t.Errorf("Recv() on empty = (%d, %v, %v), want (-1, nil, Closed)", idx, x, stat)
+	}
+}
+
+func TestSelectorAddSendOnly(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Add() did not panic")
+		}
+	}()
+	NewSelector().Add(make(chan<- int))
+}
+
+func BenchmarkSelectorRecv(b *testing.B) {
+	chs := []chan *int{make(chan *int, 1), make(chan *int, 1), make(chan *int, 1)}
+	s := NewSelector()
+	for _, ch := range chs {
+		s.Add(ch)
+	}
+	x := new(int)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		chs[i%len(chs)] <- x
+		s.Recv()
+	}
+}
+
+// BenchmarkSelectorRecvRebuild is the baseline for
+// BenchmarkSelectorRecv: the same select, but with the cases
+// built again for every receive.
+func BenchmarkSelectorRecvRebuild(b *testing.B) {
+	chs := []chan *int{make(chan *int, 1), make(chan *int, 1), make(chan *int, 1)}
+	ifaces := make([]interface{}, len(chs))
+	for i, ch := range chs {
+		ifaces[i] = ch
+	}
+	x := new(int)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		chs[i%len(chs)] <- x
+		_, xv, _ := doSelect(recvCases(ifaces))
+		xv.Interface()
+	}
+}
@@ -0,0 +1,43 @@
+package chops
+
+import "sync"
+
+// Signal wakes any number of waiting goroutines at once, like
+// sync.Cond's Broadcast, but waiters receive from a channel so
+// that they can select on it alongside other channels. Unlike
+// a channel that is closed once, a Signal can be broadcast
+// repeatedly. The zero value is ready to use. A Signal must
+// not be copied after first use.
+type Signal struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+// NewSignal returns a new Signal.
+func NewSignal() *Signal {
+	return &Signal{}
+}
+
+// Wait returns a channel that is closed by the next call to
+// Broadcast. Every call to Wait between two broadcasts returns
+// the same channel.
+func (s *Signal) Wait() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	return s.ch
+}
+
+// Broadcast wakes every goroutine waiting on a channel
+// returned by Wait. Later calls to Wait return a fresh
+// channel for the next Broadcast.
+func (s *Signal) Broadcast() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ch != nil {
+		close(s.ch)
+		s.ch = nil
+	}
+}
@@ -0,0 +1,55 @@
+package chops
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSignal(t *testing.T) {
+	const waiters = 10
+	s := NewSignal()
+
+	for round := 0; round < 3; round++ {
+		var ready, woken sync.WaitGroup
+		ready.Add(waiters)
+		woken.Add(waiters)
+		for i := 0; i < waiters; i++ {
+			go func() {
+				ch := s.Wait()
+				ready.Done()
+				<-ch
+				woken.Done()
+			}()
+		}
+		ready.Wait()
+
+		done := make(chan struct{})
+		go func() {
+			woken.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			t.Fatalf("round %d: waiters woke before Broadcast", round)
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		s.Broadcast()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("round %d: not all waiters woke after Broadcast", round)
+		}
+	}
+}
+
+func TestSignalBroadcastNoWaiters(t *testing.T) {
+	var s Signal
+	s.Broadcast()
+	select {
+	case <-s.Wait():
+		t.Error("Wait() returned a closed channel after an earlier Broadcast")
+	default:
+	}
+}
@@ -0,0 +1,183 @@
+package chops
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+)
+
+// MakeFanInSpill merges the channels in chs into a single
+// output channel with capacity outCap, like a fan-in, but
+// never applies backpressure to the inputs. When the output
+// channel is full, values are encoded with encode and
+// appended to a spill file in spillDir (the default temporary
+// directory if spillDir is empty). They are decoded with
+// decode and replayed onto the output as the consumer catches
+// up. Values are emitted in the order they were received, so
+// once anything has been spilled, newer values are spilled
+// behind it until the spill file drains.
+//
+// The spill file is a sequence of records, each a 4-byte
+// big-endian length followed by that many bytes of encoded
+// value. It is truncated whenever it drains and removed once
+// all the inputs are closed and every value has been sent,
+// after which the output channel is closed.
+//
+// If encode fails for a value, the spilled backlog and then
+// the value itself are sent to the output, blocking the
+// inputs until that completes. A failure to decode a spilled
+// value or to access the spill file is unrecoverable and
+// causes a panic.
+//
+// Options may be given among the channels in chs.
+// An error is returned if the spill file cannot be created.
+// If any other element of chs is not a channel,
+// MakeFanInSpill will panic.
+func MakeFanInSpill(outCap int, spillDir string,
+	encode func(interface{}) ([]byte, error),
+	decode func([]byte) (interface{}, error),
+	chs ...interface{}) (chan interface{}, error) {
+	chs, opts := splitOptions(chs)
+	o := applyOptions(opts)
+	// The last case is reserved for replaying onto out
+	cases := make([]reflect.SelectCase, len(chs)+1)
+	for i, ch := range chs {
+		cases[i] = reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: assertChanValue(ch),
+		}
+	}
+
+	f, err := os.CreateTemp(spillDir, "chops-spill-*")
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan interface{}, outCap)
+	o.spawn(func(op *operator) {
+		s := spillLog{f: f}
+		defer o.traceClose()
+		defer close(out)
+		defer s.remove()
+
+		outv := reflect.ValueOf(out)
+		sendIdx := len(chs)
+		remaining := len(chs)
+		var next interface{}
+		hasNext := false
+
+		for remaining > 0 || hasNext || s.n > 0 {
+			if !hasNext && s.n > 0 {
+				next = s.pop(decode)
+				hasNext = true
+			}
+			if hasNext {
+				cases[sendIdx] = reflect.SelectCase{
+					Dir:  reflect.SelectSend,
+					Chan: outv,
+					Send: reflect.ValueOf(&next).Elem(),
+				}
+			} else {
+				// A recv case on the zero Value is ignored
+				cases[sendIdx] = reflect.SelectCase{Dir: reflect.SelectRecv}
+			}
+
+			i, x, ok := doSelect(cases)
+			if i == sendIdx {
+				o.traceSend()
+				next, hasNext = nil, false
+				continue
+			}
+			if !ok {
+				cases[i].Chan = reflect.Value{}
+				remaining--
+				continue
+			}
+			o.traceRecv(op)
+
+			xi := x.Interface()
+			if !hasNext && s.n == 0 {
+				select {
+				case out <- xi:
+					o.traceSend()
+					continue
+				default:
+				}
+			}
+
+			b, err := encode(xi)
+			if err != nil {
+				if hasNext {
+					out <- next
+					o.traceSend()
+					next, hasNext = nil, false
+				}
+				for s.n > 0 {
+					out <- s.pop(decode)
+					o.traceSend()
+				}
+				out <- xi
+				o.traceSend()
+				continue
+			}
+			s.push(b)
+		}
+	})
+
+	return out, nil
+}
+
+// spillLog is a FIFO of byte records backed by a file.
+// Records are written at w and read from r.
+type spillLog struct {
+	f    *os.File
+	r, w int64
+	n    int
+}
+
+func (s *spillLog) push(b []byte) {
+	var hdr [4]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(b)))
+	if _, err := s.f.WriteAt(hdr[:], s.w); err != nil {
+		panic(fmt.Sprintf("chops: spill log write: %v", err))
+	}
+	if _, err := s.f.WriteAt(b, s.w+int64(len(hdr))); err != nil {
+		panic(fmt.Sprintf("chops: spill log write: %v", err))
+	}
+	s.w += int64(len(hdr) + len(b))
+	s.n++
+}
+
+func (s *spillLog) pop(decode func([]byte) (interface{}, error)) interface{} {
+	var hdr [4]byte
+	if _, err := s.f.ReadAt(hdr[:], s.r); err != nil {
+		panic(fmt.Sprintf("chops: spill log read: %v", err))
+	}
+	b := make([]byte, binary.BigEndian.Uint32(hdr[:]))
+	if _, err := s.f.ReadAt(b, s.r+int64(len(hdr))); err != nil && err != io.EOF {
+		panic(fmt.Sprintf("chops: spill log read: %v", err))
+	}
+	s.r += int64(len(hdr) + len(b))
+	s.n--
+
+	if s.n == 0 {
+		// Reclaim the space once everything is replayed
+		if err := s.f.Truncate(0); err != nil {
+			panic(fmt.Sprintf("chops: spill log truncate: %v", err))
+		}
+		s.r, s.w = 0, 0
+	}
+
+	x, err := decode(b)
+	if err != nil {
+		panic(fmt.Sprintf("chops: spill log decode: %v", err))
+	}
+	return x
+}
+
+func (s *spillLog) remove() {
+	s.f.Close()
+	os.Remove(s.f.Name())
+}
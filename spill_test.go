@@ -0,0 +1,115 @@
+package chops
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func encodeInt(x interface{}) ([]byte, error) {
+	i, ok := x.(int)
+	if !ok {
+		return nil, errors.New("not an int")
+	}
+	return []byte(strconv.Itoa(i)), nil
+}
+
+func decodeInt(b []byte) (interface{}, error) {
+	return strconv.Atoi(string(b))
+}
+
+func TestMakeFanInSpill(t *testing.T) {
+	const n = 100
+	dir := t.TempDir()
+	a, b := make(chan int), make(chan int)
+	out, err := MakeFanInSpill(0, dir, encodeInt, decodeInt, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nobody reads out yet, so sends only complete if the
+	// aggregator spills them
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < n; i++ {
+			a <- i
+			b <- n + i
+		}
+		close(a)
+		close(b)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("inputs blocked, values were not spilled")
+	}
+
+	lastA, lastB := -1, n-1
+	count := 0
+	for x := range out {
+		i := x.(int)
+		if i < n {
+			if i != lastA+1 {
+				t.Fatalf("got %d from a after %d", i, lastA)
+			}
+			lastA = i
+		} else {
+			if i != lastB+1 {
+				t.Fatalf("got %d from b after %d", i, lastB)
+			}
+			lastB = i
+		}
+		count++
+	}
+	if count != 2*n {
+		t.Errorf("received %d values, want %d", count, 2*n)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("spill dir not cleaned up: %v", entries)
+	}
+}
+
+func TestMakeFanInSpillEncodeError(t *testing.T) {
+	a := make(chan interface{})
+	out, err := MakeFanInSpill(0, t.TempDir(), encodeInt, decodeInt, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		a <- 1
+		a <- "not spillable"
+		a <- 2
+		close(a)
+	}()
+
+	var got []interface{}
+	for x := range out {
+		got = append(got, x)
+	}
+	want := []interface{}{1, "not spillable", 2}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestMakeFanInSpillBadDir(t *testing.T) {
+	_, err := MakeFanInSpill(0, "/nonexistent/chops", encodeInt, decodeInt)
+	if err == nil {
+		t.Error("expected error for missing spill dir")
+	}
+}
@@ -0,0 +1,130 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+)
+
+// TeeSynced sends every value received from the channel in to
+// both of the returned channels, which have capacity bufCap.
+// The next value is not received from in until both outputs
+// have accepted the current one, so the faster consumer is
+// held back to within bufCap+1 values of the slower one: the
+// slower output's bufCap buffered values, plus the value it
+// has yet to accept. This keeps the two consumers aligned,
+// for example a live view and a recording of the same
+// stream. When in is closed, both outputs are closed.
+//
+// Given an overflow policy other than Block with WithOverflow,
+// an output that isn't ready for a value hands it to the
+// policy instead, first for out1 and then for out2. A stalled
+// consumer then no longer holds back the other one, at the
+// cost of the two no longer being aligned.
+//
+// If in is not a channel, TeeSynced will panic.
+func TeeSynced(bufCap int, in interface{}, opts ...Option) (out1, out2 chan interface{}) {
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	out1 = make(chan interface{}, bufCap)
+	out2 = make(chan interface{}, bufCap)
+	s1, s2 := o.sender(out1, nil, Block), o.sender(out2, nil, Block)
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer s1.close()
+		defer s2.close()
+		for {
+			x, ok := inv.Recv()
+			if !ok {
+				return
+			}
+			o.traceRecv(op)
+			if o.overflow != nil && o.overflow != Block {
+				s1.send(x)
+				s2.send(x)
+				continue
+			}
+			xi := x.Interface()
+
+			// Send to both in whichever order they're ready,
+			// so neither consumer has to read first
+			o1, o2 := out1, out2
+			for o1 != nil || o2 != nil {
+				select {
+				case o1 <- xi:
+					o1 = nil
+				case o2 <- xi:
+					o2 = nil
+				}
+				o.traceSend()
+			}
+		}
+	})
+
+	return out1, out2
+}
+
+// TeeOrdered is like TeeSynced, but typed. Both outputs
+// receive every value from in, in the order it was received.
+//
+// The outputs are not in lock-step, but how far one can get
+// ahead of the other is bounded: a consumer can have received
+// at most bufCap+1 values more than the other consumer. The
+// next value is not received from in until both outputs have
+// accepted the current one, so the leading consumer can drain
+// the lagging output's bufCap buffered values' worth plus the
+// value in flight, and must then wait. With bufCap 0, the
+// consumers are never more than one value apart. When in is
+// closed or ctx is done, both outputs are closed.
+//
+// An overflow policy other than Block gives up that bound, as
+// it does for TeeSynced, and the values a consumer misses are
+// left out of its output without changing the order of the
+// rest.
+func TeeOrdered[T any](ctx context.Context, bufCap int, in <-chan T,
+	opts ...Option) (<-chan T, <-chan T) {
+	o := applyOptions(opts)
+	out1 := make(chan T, bufCap)
+	out2 := make(chan T, bufCap)
+	s1 := o.sender(out1, ctx.Done(), Block)
+	s2 := o.sender(out2, ctx.Done(), Block)
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer s1.close()
+		defer s2.close()
+		for {
+			var x T
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				x = v
+			case <-ctx.Done():
+				return
+			}
+			o.traceRecv(op)
+			if o.overflow != nil && o.overflow != Block {
+				xv := reflect.ValueOf(&x).Elem()
+				s1.send(xv)
+				s2.send(xv)
+				continue
+			}
+			o1, o2 := out1, out2
+			for o1 != nil || o2 != nil {
+				select {
+				case o1 <- x:
+					o1 = nil
+				case o2 <- x:
+					o2 = nil
+				case <-ctx.Done():
+					return
+				}
+				o.traceSend()
+			}
+		}
+	})
+
+	return out1, out2
+}
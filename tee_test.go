@@ -0,0 +1,124 @@
+package chops
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTeeSynced(t *testing.T) {
+	const n = 10
+	in := make(chan int)
+	go func() {
+		for i := 0; i < n; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+	out1, out2 := TeeSynced(1, in)
+
+	// out2 is slow, so out1 can only get ahead by the buffer
+	// and the value in flight
+	slowDone := make(chan struct{})
+	var received2 int
+	go func() {
+		defer close(slowDone)
+		for x := range out2 {
+			if x != received2 {
+				t.Errorf("out2 received %v, want %d", x, received2)
+			}
+			time.Sleep(5 * time.Millisecond)
+			received2++
+		}
+	}()
+
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		if x := <-out1; x != i {
+			t.Fatalf("out1 received %v, want %d", x, i)
+		}
+	}
+	if _, ok := <-out1; ok {
+		t.Error("out1 not closed")
+	}
+	<-slowDone
+
+	if d := time.Since(start); d < (n-3)*5*time.Millisecond {
+		t.Errorf("out1 finished in %v, not paced by out2", d)
+	}
+	if received2 != n {
+		t.Errorf("out2 received %d values, want %d", received2, n)
+	}
+}
+
+func TestTeeOrderedLag(t *testing.T) {
+	for _, bufCap := range []int{0, 1, 3} {
+		bufCap := bufCap
+		t.Run(fmt.Sprint(bufCap), func(t *testing.T) {
+			in := make(chan int)
+			go func() {
+				defer close(in)
+				for i := 0; i < 100; i++ {
+					in <- i
+				}
+			}()
+			fast, slow := TeeOrdered(context.Background(), bufCap, in)
+
+			// slow isn't read at all, so fast gets exactly
+			// bufCap+1 values ahead
+			ahead := 0
+			for {
+				x, stat := RecvTimeout(fast, 20*time.Millisecond)
+				if stat == TimedOut {
+					break
+				}
+				if x != ahead {
+					t.Fatalf("fast received %d, want %d", x, ahead)
+				}
+				ahead++
+			}
+			if ahead != bufCap+1 {
+				t.Errorf("fast got %d values ahead, want %d", ahead, bufCap+1)
+			}
+
+			// Every value slow catches up on lets fast
+			// advance by one
+			for i := 0; i < 3; i++ {
+				if x := <-slow; x != i {
+					t.Fatalf("slow received %d, want %d", x, i)
+				}
+				x, stat := RecvTimeout(fast, time.Second)
+				if stat != Ok || x != ahead {
+					t.Fatalf("fast received %d, %v, want %d, Ok", x, stat, ahead)
+				}
+				ahead++
+				if _, stat := RecvTimeout(fast, 20*time.Millisecond); stat != TimedOut {
+					t.Fatalf("fast got more than %d values ahead", bufCap+1)
+				}
+			}
+
+			go collect(slow)
+			collect(fast)
+		})
+	}
+}
+
+func TestTeeOrderedSequence(t *testing.T) {
+	in := source(1, 2, 3, 4, 5)
+	out1, out2 := TeeOrdered(context.Background(), 2, in)
+	var got1 []int
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		got1 = collect(out1)
+	}()
+	got2 := collect(out2)
+	<-done
+
+	want := []int{1, 2, 3, 4, 5}
+	if !reflect.DeepEqual(got1, want) || !reflect.DeepEqual(got2, want) {
+		t.Errorf("outputs received %v and %v, want %v twice", got1, got2, want)
+	}
+}
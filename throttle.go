@@ -0,0 +1,69 @@
+package chops
+
+import (
+	"fmt"
+	"time"
+)
+
+// MakeTokenBucket forwards values from the channel in to the
+// returned channel with capacity outCap, limiting the rate
+// with a token bucket. The bucket holds up to burst tokens
+// and starts full. Tokens are added continuously at rate per
+// second, and each forwarded value consumes one, waiting for
+// a token to become available if there are none. This lets
+// short bursts of up to burst values through immediately,
+// while the average rate converges to rate. When in is
+// closed, the output is closed.
+//
+// A value that the output isn't ready for once its token is
+// available is handed to the overflow policy given with
+// WithOverflow, Block by default. With a policy that drops,
+// a slow consumer loses values instead of slowing down the
+// producer further.
+//
+// MakeTokenBucket will panic if rate is not positive, if
+// burst is less than 1, or if in is not a channel.
+func MakeTokenBucket(outCap int, rate float64, burst int, in interface{},
+	opts ...Option) chan interface{} {
+	if rate <= 0 || burst < 1 {
+		panic(fmt.Sprintf("invalid token bucket: rate=%v burst=%d", rate, burst))
+	}
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	out := make(chan interface{}, outCap)
+
+	s := o.sender(out, nil, Block)
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer s.close()
+		tokens := float64(burst)
+		last := time.Now()
+
+		for {
+			x, ok := inv.Recv()
+			if !ok {
+				return
+			}
+			o.traceRecv(op)
+
+			now := time.Now()
+			tokens += now.Sub(last).Seconds() * rate
+			if tokens > float64(burst) {
+				tokens = float64(burst)
+			}
+			last = now
+
+			if tokens < 1 {
+				wait := time.Duration((1 - tokens) / rate * float64(time.Second))
+				time.Sleep(wait)
+				tokens = 1
+				last = last.Add(wait)
+			}
+			tokens--
+			s.send(x)
+		}
+	})
+
+	return out
+}
@@ -0,0 +1,49 @@
+package chops
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMakeTokenBucket(t *testing.T) {
+	const burst, rest = 5, 10
+	const rate = 20.0
+	in := make(chan int, burst+rest)
+	for i := 0; i < burst+rest; i++ {
+		in <- i
+	}
+	close(in)
+
+	start := time.Now()
+	out := MakeTokenBucket(0, rate, burst, in)
+
+	for i := 0; i < burst; i++ {
+		<-out
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("burst of %d took %v, want immediate", burst, d)
+	}
+
+	n := 0
+	for range out {
+		n++
+	}
+	if n != rest {
+		t.Errorf("received %d values after burst, want %d", n, rest)
+	}
+
+	// The rest are paced at rate
+	want := time.Duration(rest / rate * float64(time.Second))
+	if d := time.Since(start); d < want*9/10 || d > want*2 {
+		t.Errorf("took %v, want about %v", d, want)
+	}
+}
+
+func TestMakeTokenBucketInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MakeTokenBucket() did not panic")
+		}
+	}()
+	MakeTokenBucket(0, 0, 1, make(chan int))
+}
@@ -0,0 +1,221 @@
+package chops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// RecvTimeout receives from ch, waiting at most d for a value.
+// If the return Status is Ok, the receive succeeded. If it is
+// Closed, ch is closed and the zero value is returned. If it
+// is TimedOut, nothing was received within d.
+//
+// A value that is already waiting is received without
+// starting a timer at all. Otherwise the timer is stopped as
+// soon as RecvTimeout returns, so unlike time.After, calling
+// it in a tight loop does not leave timers behind until they
+// fire.
+func RecvTimeout[T any](ch <-chan T, d time.Duration) (T, Status) {
+	select {
+	case x, ok := <-ch:
+		return recvStatus(x, ok)
+	default:
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case x, ok := <-ch:
+		return recvStatus(x, ok)
+	case <-t.C:
+		var zero T
+		return zero, TimedOut
+	}
+}
+
+func recvStatus[T any](x T, ok bool) (T, Status) {
+	if !ok {
+		return x, Closed
+	}
+	return x, Ok
+}
+
+// SendBeforeDeadline sends x on ch, blocking until the send
+// completes or ctx is done, whichever happens first.
+// If the return Status is Ok, the send succeeded.
+// If the return Status is Closed, the channel is closed.
+// If the return Status is TimedOut, ctx's deadline passed
+// before the send could complete.
+// If the return Status is Cancelled, ctx was cancelled
+// before the send could complete.
+// If ctx is already done, nothing is sent, even if ch is
+// ready.
+//
+// If ch is not a channel, or x cannot be sent on it,
+// SendBeforeDeadline will panic.
+func SendBeforeDeadline(ch interface{}, x interface{}, ctx context.Context) Status {
+	v := assertChanValue(ch)
+	xv := reflect.ValueOf(&x).Elem()
+	if x != nil {
+		xv = xv.Elem()
+	}
+	if !xv.Type().AssignableTo(v.Type().Elem()) {
+		panic(fmt.Sprintf("cannot send %T on %T", x, ch))
+	}
+	if err := ctx.Err(); err != nil {
+		return ctxStatus(err)
+	}
+
+	i, closed := selectSend([]reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: v, Send: xv},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+	})
+	if closed {
+		return Closed
+	}
+	if i == 0 {
+		return Ok
+	}
+	return ctxStatus(ctx.Err())
+}
+
+// ctxStatus translates the error of a done context into a
+// Status.
+func ctxStatus(err error) Status {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return TimedOut
+	}
+	return Cancelled
+}
+
+// ScatterOnce sends x on every channel in chs, giving each one
+// up to timeout to accept it, and returns the outcome for each
+// channel, in the order of chs:
+// If the Status is Ok, the channel received x.
+// If the Status is Closed, the channel is closed.
+// If the Status is TimedOut, the channel did not accept x
+// within timeout.
+// Channels that are ready receive x immediately, and the
+// others wait at the same time, so ScatterOnce returns after
+// at most about timeout. If timeout is 0 or less, only ready
+// channels receive x.
+//
+// If any element of chs is not a channel that can be sent on,
+// or x cannot be sent on it, ScatterOnce will panic before
+// sending anything.
+func ScatterOnce(x interface{}, timeout time.Duration, chs ...interface{}) []Status {
+	xv := reflect.ValueOf(&x).Elem()
+	if x != nil {
+		xv = xv.Elem()
+	}
+	vs := make([]reflect.Value, len(chs))
+	for i, ch := range chs {
+		v := assertChanValue(ch)
+		if v.Type().ChanDir()&reflect.SendDir == 0 {
+			panic(fmt.Sprintf("cannot send on receive-only %T", ch))
+		}
+		if !xv.Type().AssignableTo(v.Type().Elem()) {
+			panic(fmt.Sprintf("cannot send %T on %T", x, ch))
+		}
+		vs[i] = v
+	}
+
+	// The last case is reserved for the timeout
+	stats := make([]Status, len(chs))
+	cases := make([]reflect.SelectCase, len(chs)+1)
+	timeoutIdx := len(chs)
+	pending := 0
+	probe := func(i int) {
+		stats[i] = trySendValue(vs[i], xv)
+		if stats[i] == Blocked {
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectSend, Chan: vs[i], Send: xv}
+			pending++
+		} else {
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv}
+		}
+	}
+	for i := range vs {
+		probe(i)
+	}
+
+	if pending > 0 && timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		cases[timeoutIdx] = reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(timer.C),
+		}
+		for pending > 0 {
+			i, closed := selectSend(cases)
+			if closed {
+				// One of the pending channels was closed
+				// while waiting, so find out which
+				pending = 0
+				for j := range vs {
+					if cases[j].Chan.IsValid() {
+						probe(j)
+					}
+				}
+				continue
+			}
+			if i == timeoutIdx {
+				break
+			}
+			stats[i] = Ok
+			cases[i].Chan = reflect.Value{}
+			pending--
+		}
+	}
+
+	for i := range stats {
+		if stats[i] == Blocked {
+			stats[i] = TimedOut
+		}
+	}
+	return stats
+}
+
+// trySendValue is TrySend for a channel and value that have
+// already been checked.
+func trySendValue(v, xv reflect.Value) (stat Status) {
+	defer func() {
+		if isCloseChPanic(recover()) {
+			stat = Closed
+		}
+	}()
+	if v.TrySend(xv) {
+		return Ok
+	}
+	return Blocked
+}
+
+// selectSend is reflect.Select for cases that may send on a
+// channel that gets closed, in which case it reports closed
+// instead of panicking.
+func selectSend(cases []reflect.SelectCase) (i int, closed bool) {
+	defer func() {
+		if isCloseChPanic(recover()) {
+			closed = true
+		}
+	}()
+	i, _, _ = reflect.Select(cases)
+	return i, false
+}
+
+// isCloseChPanic reports whether r, as returned by recover, is
+// from sending on a closed channel, and re-panics with any
+// other non-nil value.
+func isCloseChPanic(r interface{}) bool {
+	if r == nil {
+		return false
+	}
+	if err, ok := r.(runtime.Error); ok && strings.Contains(err.Error(), closeChMsg) {
+		return true
+	}
+	panic(r)
+}
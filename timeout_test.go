@@ -0,0 +1,195 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecvTimeout(t *testing.T) {
+	ready := make(chan int, 1)
+	ready <- 1
+	closed := make(chan int)
+	close(closed)
+	empty := make(chan int)
+
+	tests := []struct {
+		name     string
+		ch       chan int
+		want     int
+		wantStat Status
+	}{
+		{"ready", ready, 1, Ok},
+		{"closed", closed, 0, Closed},
+		{"empty", empty, 0, TimedOut},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, stat := RecvTimeout(tt.ch, 10*time.Millisecond)
+			if got != tt.want || stat != tt.wantStat {
+				t.Errorf("RecvTimeout() = %v, %v, want %v, %v",
+					got, stat, tt.want, tt.wantStat)
+			}
+		})
+	}
+}
+
+func TestRecvTimeoutLateValue(t *testing.T) {
+	ch := make(chan string)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ch <- "late"
+	}()
+	got, stat := RecvTimeout(ch, time.Second)
+	if got != "late" || stat != Ok {
+		t.Errorf("RecvTimeout() = %q, %v, want %q, Ok", got, stat, "late")
+	}
+}
+
+// recvTimeoutReflect is the equivalent of RecvTimeout for a
+// channel typed as interface{}, for comparison.
+func recvTimeoutReflect(ch interface{}, d time.Duration) (interface{}, Status) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(t.C)},
+	}
+	i, x, ok := reflect.Select(cases)
+	switch {
+	case i == 1:
+		return nil, TimedOut
+	case !ok:
+		return nil, Closed
+	default:
+		return x.Interface(), Ok
+	}
+}
+
+// The Ready benchmarks have a value waiting for every
+// receive, which RecvTimeout takes without a timer.
+
+func BenchmarkRecvTimeoutReady(b *testing.B) {
+	ch := make(chan int, 1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ch <- i
+		RecvTimeout(ch, time.Second)
+	}
+}
+
+func BenchmarkRecvTimeoutReadyReflect(b *testing.B) {
+	ch := make(chan int, 1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ch <- i
+		recvTimeoutReflect(ch, time.Second)
+	}
+}
+
+// The Expired benchmarks never receive a value, so every
+// receive starts a timer and times out.
+
+func BenchmarkRecvTimeoutExpired(b *testing.B) {
+	ch := make(chan int)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		RecvTimeout(ch, time.Microsecond)
+	}
+}
+
+func BenchmarkRecvTimeoutExpiredReflect(b *testing.B) {
+	ch := make(chan int)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		recvTimeoutReflect(ch, time.Microsecond)
+	}
+}
+
+func TestSendBeforeDeadline(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	closed := make(chan interface{})
+	close(closed)
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		ch       chan interface{}
+		x        interface{}
+		wantStat Status
+	}{
+		{"Ok", context.Background(), make(chan interface{}, 1), 1, Ok},
+		{"Nil", context.Background(), make(chan interface{}, 1), nil, Ok},
+		{"Closed", context.Background(), closed, 1, Closed},
+		{"Cancelled", cancelled, make(chan interface{}, 1), 1, Cancelled},
+		{"TimedOut", expired, make(chan interface{}), 1, TimedOut},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if stat := SendBeforeDeadline(tt.ch, tt.x, tt.ctx); stat != tt.wantStat {
+				t.Errorf("SendBeforeDeadline() = %v, want %v", stat, tt.wantStat)
+			}
+			if tt.wantStat == Ok {
+				if got := <-tt.ch; got != tt.x {
+					t.Errorf("received %v, want %v", got, tt.x)
+				}
+			}
+		})
+	}
+}
+
+func TestSendBeforeDeadlineCancelWhileBlocked(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if stat := SendBeforeDeadline(make(chan int), 1, ctx); stat != Cancelled {
+		t.Errorf("SendBeforeDeadline() = %v, want Cancelled", stat)
+	}
+}
+
+func TestScatterOnce(t *testing.T) {
+	const timeout = 20 * time.Millisecond
+	ready := make(chan string, 1)
+	full := make(chan string, 1)
+	full <- "old"
+	closed := make(chan string)
+	close(closed)
+	late := make(chan string)
+	go func() {
+		time.Sleep(timeout / 4)
+		<-late
+	}()
+	var iface chan interface{} = make(chan interface{}, 1)
+
+	start := time.Now()
+	got := ScatterOnce("msg", timeout, ready, full, closed, late, iface)
+	if d := time.Since(start); d > timeout+50*time.Millisecond {
+		t.Errorf("ScatterOnce() took %v, want about %v", d, timeout)
+	}
+	want := []Status{Ok, TimedOut, Closed, Ok, Ok}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ScatterOnce() = %v, want %v", got, want)
+	}
+	if x := <-ready; x != "msg" {
+		t.Errorf("ready received %q, want msg", x)
+	}
+	if x := <-full; x != "old" {
+		t.Errorf("full received %q, want old", x)
+	}
+}
+
+func TestScatterOnceMismatch(t *testing.T) {
+	ok := make(chan int, 1)
+	defer func() {
+		if recover() == nil {
+			t.Error("ScatterOnce() did not panic")
+		}
+		if len(ok) != 0 {
+			t.Error("ScatterOnce() sent before checking every channel")
+		}
+	}()
+	ScatterOnce(1, 0, ok, make(chan string, 1))
+}
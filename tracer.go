@@ -0,0 +1,65 @@
+package chops
+
+// Tracer receives events from the goroutines that chops
+// operators spawn to forward values. Each event is tagged
+// with the stage name that the Tracer was registered with,
+// so that a single Tracer can observe a whole pipeline of
+// composed operators.
+//
+// Tracer methods are called synchronously from the
+// forwarding goroutine, so they should return quickly.
+// Operators that forward with several goroutines, such as
+// MergeG and ScatterGather, call them concurrently. An
+// operator with DropOldest also sends from a goroutine of its
+// own for each output, so its OnSend calls may be concurrent
+// with its other events, and may follow OnClose for values
+// that were still buffered when the input closed.
+type Tracer interface {
+	// OnRecv is called after the stage receives a value.
+	OnRecv(stage string)
+	// OnSend is called after the stage sends a value.
+	OnSend(stage string)
+	// OnClose is called when the stage closes its output.
+	OnClose(stage string)
+}
+
+// WithTracer registers t to receive the operator's events,
+// tagged with stage. stage also labels the operator's
+// goroutines, as with WithLabel.
+func WithTracer(stage string, t Tracer) Option {
+	return func(o *options) {
+		o.stage = stage
+		o.tracer = t
+	}
+}
+
+// WithLabel names the operator's stage without tracing it.
+// The label shows up in ActiveOperators.
+func WithLabel(stage string) Option {
+	return func(o *options) {
+		o.stage = stage
+	}
+}
+
+// The trace methods are no-ops without a Tracer, so callers
+// don't need to check for one in their loops. traceRecv also
+// counts the value towards op's Processed.
+
+func (o *options) traceRecv(op *operator) {
+	op.count()
+	if o.tracer != nil {
+		o.tracer.OnRecv(o.stage)
+	}
+}
+
+func (o *options) traceSend() {
+	if o.tracer != nil {
+		o.tracer.OnSend(o.stage)
+	}
+}
+
+func (o *options) traceClose() {
+	if o.tracer != nil {
+		o.tracer.OnClose(o.stage)
+	}
+}
@@ -0,0 +1,132 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingTracer logs every event as "stage:event".
+type recordingTracer struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recordingTracer) record(s string) {
+	r.mu.Lock()
+	r.events = append(r.events, s)
+	r.mu.Unlock()
+}
+
+func (r *recordingTracer) OnRecv(stage string)  { r.record(stage + ":recv") }
+func (r *recordingTracer) OnSend(stage string)  { r.record(stage + ":send") }
+func (r *recordingTracer) OnClose(stage string) { r.record(stage + ":close") }
+
+func (r *recordingTracer) Events() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func TestWithTracer(t *testing.T) {
+	tr := &recordingTracer{}
+	in := make(chan int)
+	out := MapG(context.Background(), in, func(x int) int { return x * 2 },
+		WithTracer("double", tr))
+	for i := 0; i < 2; i++ {
+		in <- i
+		<-out
+	}
+	close(in)
+	for range out {
+	}
+
+	want := []string{
+		"double:recv", "double:send",
+		"double:recv", "double:send",
+		"double:close",
+	}
+	eventually(t, "close event", func() bool { return len(tr.Events()) == len(want) })
+	if got := tr.Events(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestWithTracerPipeline(t *testing.T) {
+	tr := &recordingTracer{}
+	ch1, ch2 := source(1, 2), source(3)
+	merged := MakeFanInSummary(0, 0, func(acc, v interface{}) interface{} {
+		return acc.(int) + v.(int)
+	}, ch1, ch2, WithTracer("merge", tr))
+	a, b := TeeSynced(4, merged, WithTracer("tee", tr))
+	collect[interface{}](a)
+	collect[interface{}](b)
+
+	want := map[string]int{
+		// 3 values and the Summary
+		"merge:recv": 3, "merge:send": 4, "merge:close": 1,
+		"tee:recv": 4, "tee:send": 8, "tee:close": 1,
+	}
+	eventually(t, "close events", func() bool {
+		n := 0
+		for _, e := range tr.Events() {
+			if strings.HasSuffix(e, ":close") {
+				n++
+			}
+		}
+		return n == 2
+	})
+	got := make(map[string]int)
+	for _, e := range tr.Events() {
+		got[e]++
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("event counts = %v, want %v", got, want)
+	}
+}
+
+func TestNilTracerAllocs(t *testing.T) {
+	o := applyOptions(nil)
+	allocs := testing.AllocsPerRun(100, func() {
+		o.traceRecv(nil)
+		o.traceSend()
+		o.traceClose()
+	})
+	if allocs != 0 {
+		t.Errorf("nil tracer allocs = %v, want 0", allocs)
+	}
+}
+
+func TestWithTracerConcurrent(t *testing.T) {
+	tr := &recordingTracer{}
+	ctx := context.Background()
+	merged := MergeG(ctx, []<-chan int{source(1, 2), source(3)},
+		WithTracer("merge", tr))
+	out := ScatterGather(ctx, 2, func(x int) int { return -x }, merged,
+		WithTracer("scatter", tr))
+	collect(out)
+
+	// Each has several goroutines, but closes its output once
+	want := map[string]int{
+		"merge:recv": 3, "merge:send": 3, "merge:close": 1,
+		"scatter:recv": 3, "scatter:send": 3, "scatter:close": 1,
+	}
+	eventually(t, "close events", func() bool {
+		n := 0
+		for _, e := range tr.Events() {
+			if strings.HasSuffix(e, ":close") {
+				n++
+			}
+		}
+		return n == 2
+	})
+	got := make(map[string]int)
+	for _, e := range tr.Events() {
+		got[e]++
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("event counts = %v, want %v", got, want)
+	}
+}
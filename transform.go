@@ -0,0 +1,174 @@
+package chops
+
+import "context"
+
+// MakeFilterMap sends the result of f on the returned channel,
+// which has capacity outCap, for every value received from the
+// channel in, but only if f also returns true. When in is
+// closed, the output is closed.
+//
+// If in is not a channel, MakeFilterMap will panic.
+func MakeFilterMap(outCap int, f func(interface{}) (interface{}, bool),
+	in interface{}, opts ...Option) chan interface{} {
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	out := make(chan interface{}, outCap)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		for {
+			x, ok := inv.Recv()
+			if !ok {
+				return
+			}
+			o.traceRecv(op)
+			if y, keep := f(x.Interface()); keep {
+				out <- y
+				o.traceSend()
+			}
+		}
+	})
+	return out
+}
+
+// MakePairwise sends [previous, current] on the returned
+// channel, which has capacity outCap, for every value received
+// from the channel in after the first, which has no
+// predecessor. A stream of a single value therefore produces
+// nothing. When in is closed, the output is closed.
+//
+// If in is not a channel, MakePairwise will panic.
+func MakePairwise(outCap int, in interface{}, opts ...Option) chan [2]interface{} {
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	out := make(chan [2]interface{}, outCap)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		x, ok := inv.Recv()
+		if !ok {
+			return
+		}
+		o.traceRecv(op)
+		prev := x.Interface()
+		for {
+			x, ok := inv.Recv()
+			if !ok {
+				return
+			}
+			o.traceRecv(op)
+			cur := x.Interface()
+			out <- [2]interface{}{prev, cur}
+			o.traceSend()
+			prev = cur
+		}
+	})
+	return out
+}
+
+// RunLength is a run of consecutive equal values, as sent by
+// MakeRunLength.
+type RunLength struct {
+	Value interface{}
+	Count int
+}
+
+// MakeRunLength groups consecutive values received from the
+// channel in that are equal according to eq, and sends each
+// run on the returned channel, which has capacity outCap, as
+// the first value of the run and its length. A run is sent
+// once a value that differs from it arrives, and the last run
+// is sent when in is closed, before the output is closed. If
+// eq is nil, values are compared with ==, which panics if
+// they are not comparable.
+//
+// If in is not a channel, MakeRunLength will panic.
+func MakeRunLength(outCap int, eq func(a, b interface{}) bool, in interface{},
+	opts ...Option) chan RunLength {
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	if eq == nil {
+		eq = func(a, b interface{}) bool { return a == b }
+	}
+	out := make(chan RunLength, outCap)
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		var run RunLength
+		for {
+			x, ok := inv.Recv()
+			if !ok {
+				break
+			}
+			o.traceRecv(op)
+			cur := x.Interface()
+			if run.Count > 0 && eq(run.Value, cur) {
+				run.Count++
+				continue
+			}
+			if run.Count > 0 {
+				out <- run
+				o.traceSend()
+			}
+			run = RunLength{cur, 1}
+		}
+		if run.Count > 0 {
+			out <- run
+			o.traceSend()
+		}
+	})
+	return out
+}
+
+// Run is the typed counterpart of RunLength.
+type Run[T any] struct {
+	Value T
+	Count int
+}
+
+// RunLengthG is like MakeRunLength, but typed, and compares
+// values with ==. It follows the lifecycle convention of the
+// generic stages, and if ctx is done, the run in progress is
+// discarded.
+func RunLengthG[T comparable](ctx context.Context, in <-chan T, opts ...Option) <-chan Run[T] {
+	o := applyOptions(opts)
+	out := make(chan Run[T])
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		var run Run[T]
+		send := func() bool {
+			if run.Count == 0 {
+				return true
+			}
+			select {
+			case out <- run:
+				o.traceSend()
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for {
+			select {
+			case x, ok := <-in:
+				if !ok {
+					send()
+					return
+				}
+				o.traceRecv(op)
+				if run.Count > 0 && run.Value == x {
+					run.Count++
+					continue
+				}
+				if !send() {
+					return
+				}
+				run = Run[T]{x, 1}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
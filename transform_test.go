@@ -0,0 +1,96 @@
+package chops
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMakeFilterMap(t *testing.T) {
+	in := make(chan string, 4)
+	for _, s := range []string{"1", "x", "2", "3"} {
+		in <- s
+	}
+	close(in)
+
+	out := MakeFilterMap(0, func(x interface{}) (interface{}, bool) {
+		i, err := strconv.Atoi(x.(string))
+		return i * 10, err == nil
+	}, in)
+
+	var got []interface{}
+	for x := range out {
+		got = append(got, x)
+	}
+	if want := []interface{}{10, 20, 30}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MakeFilterMap() = %v, want %v", got, want)
+	}
+}
+
+func TestMakePairwise(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []int
+		want [][2]interface{}
+	}{
+		{"Empty", nil, nil},
+		{"Single", []int{1}, nil},
+		{"Many", []int{1, 2, 4}, [][2]interface{}{{1, 2}, {2, 4}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][2]interface{}
+			for p := range MakePairwise(0, source(tt.in...)) {
+				got = append(got, p)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MakePairwise() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeRunLength(t *testing.T) {
+	caseless := func(a, b interface{}) bool {
+		return strings.EqualFold(a.(string), b.(string))
+	}
+	tests := []struct {
+		name string
+		eq   func(a, b interface{}) bool
+		in   []string
+		want []RunLength
+	}{
+		{"Empty", nil, nil, nil},
+		{"Single", nil, []string{"A"}, []RunLength{{"A", 1}}},
+		{
+			"Runs",
+			nil,
+			[]string{"A", "A", "A", "B", "B", "A"},
+			[]RunLength{{"A", 3}, {"B", 2}, {"A", 1}},
+		},
+		{
+			"Eq",
+			caseless,
+			[]string{"a", "A", "b", "B", "b"},
+			[]RunLength{{"a", 2}, {"b", 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := collect(MakeRunLength(0, tt.eq, source(tt.in...)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MakeRunLength() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunLengthG(t *testing.T) {
+	got := collect(RunLengthG(context.Background(), source("A", "A", "A", "B", "B", "A")))
+	want := []Run[string]{{"A", 3}, {"B", 2}, {"A", 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RunLengthG() = %v, want %v", got, want)
+	}
+}
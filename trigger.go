@@ -0,0 +1,63 @@
+package chops
+
+import "reflect"
+
+// MakeBufferUntil collects the values received from the
+// channel in, and each time a value is received from trigger,
+// sends everything collected since the previous flush as one
+// slice on the returned channel, which has capacity outCap.
+// This lets an external scheduler decide where batches end,
+// instead of a count or a timer. If skipEmpty is true, a
+// trigger with nothing collected is ignored; otherwise it
+// sends an empty, non-nil slice. When in is closed, whatever
+// was collected is sent as a final batch, if skipEmpty allows,
+// and the output is closed. If trigger is closed, no more
+// batches are sent until in is closed.
+//
+// While a batch waits to be received from the output, neither
+// in nor trigger is received from.
+//
+// If in is not a channel, MakeBufferUntil will panic.
+func MakeBufferUntil(outCap int, trigger <-chan struct{}, skipEmpty bool,
+	in interface{}, opts ...Option) chan []interface{} {
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+	out := make(chan []interface{}, outCap)
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: inv},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(trigger)},
+		}
+		triggerIdx := 1
+		batch := []interface{}{}
+		flush := func() {
+			if len(batch) == 0 && skipEmpty {
+				return
+			}
+			out <- batch
+			o.traceSend()
+			batch = []interface{}{}
+		}
+
+		for {
+			i, x, ok := reflect.Select(cases)
+			switch {
+			case i == triggerIdx && !ok:
+				cases[triggerIdx].Chan = reflect.Value{}
+			case i == triggerIdx:
+				flush()
+			case !ok:
+				flush()
+				return
+			default:
+				o.traceRecv(op)
+				batch = append(batch, x.Interface())
+			}
+		}
+	})
+
+	return out
+}
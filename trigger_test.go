@@ -0,0 +1,57 @@
+package chops
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMakeBufferUntil(t *testing.T) {
+	tests := []struct {
+		name      string
+		skipEmpty bool
+		want      [][]interface{}
+	}{
+		{"KeepEmpty", false, [][]interface{}{{1, 2}, {}, {3}, {4, 5}}},
+		{"SkipEmpty", true, [][]interface{}{{1, 2}, {3}, {4, 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Unbuffered, so each send is received before the
+			// next trigger
+			in := make(chan int)
+			trigger := make(chan struct{})
+			out := MakeBufferUntil(4, trigger, tt.skipEmpty, in)
+
+			in <- 1
+			in <- 2
+			trigger <- struct{}{}
+			trigger <- struct{}{}
+			in <- 3
+			trigger <- struct{}{}
+			in <- 4
+			in <- 5
+			// The final batch is sent without a trigger
+			close(in)
+
+			if got := collect(out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("batches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeBufferUntilClosedTrigger(t *testing.T) {
+	in := make(chan int)
+	trigger := make(chan struct{})
+	close(trigger)
+	out := MakeBufferUntil(0, trigger, true, in)
+	go func() {
+		defer close(in)
+		for i := 0; i < 3; i++ {
+			in <- i
+		}
+	}()
+	if got, want := collect(out), [][]interface{}{{0, 1, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("batches = %v, want %v", got, want)
+	}
+}
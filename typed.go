@@ -0,0 +1,57 @@
+package chops
+
+import "reflect"
+
+// typeMatches reports whether x's dynamic type is want, or
+// implements want if want is an interface type.
+func typeMatches(x interface{}, want reflect.Type) bool {
+	xt := reflect.TypeOf(x)
+	if xt == nil {
+		return false
+	}
+	if want.Kind() == reflect.Interface {
+		return xt.Implements(want)
+	}
+	return xt == want
+}
+
+// RecvTyped blocks until a value can be received from a
+// channel, and reports whether the value's dynamic type
+// matches want. If want is an interface type, any value
+// implementing it matches. This suits routing on a channel of
+// polymorphic messages, such as a `chan interface{}` of
+// events.
+// If the return Status is Ok, the return interface{} is the
+// received value, matching or not, so that the caller can
+// decide what to do with it.
+// If the return Status is Closed, the channel is closed and
+// the return interface{} will be the zero value of the
+// channel's element type, which only matches want if the
+// element type itself does.
+func RecvTyped(ch interface{}, want reflect.Type) (interface{}, bool, Status) {
+	v := assertChanValue(ch)
+	x, ok := v.Recv()
+	xi := x.Interface()
+	if !ok {
+		return xi, typeMatches(xi, want), Closed
+	}
+	return xi, typeMatches(xi, want), Ok
+}
+
+// DrainMatching receives from a channel without blocking
+// until it is empty or closed, and returns the received values
+// whose dynamic type matches want, as in RecvTyped. Values that
+// don't match are discarded.
+func DrainMatching(ch interface{}, want reflect.Type) []interface{} {
+	v := assertChanValue(ch)
+	var matched []interface{}
+	for {
+		x, ok := v.TryRecv()
+		if !ok {
+			return matched
+		}
+		if xi := x.Interface(); typeMatches(xi, want) {
+			matched = append(matched, xi)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package chops
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRecvTyped(t *testing.T) {
+	errType := reflect.TypeOf((*error)(nil)).Elem()
+	tests := []struct {
+		name      string
+		chFactory func() interface{}
+		want      reflect.Type
+		wantX     interface{}
+		wantMatch bool
+		wantStat  Status
+	}{
+		{
+			"Match",
+			func() interface{} {
+				ch := make(chan interface{}, 1)
+				ch <- 1
+				return ch
+			},
+			reflect.TypeOf(0),
+			1,
+			true,
+			Ok,
+		},
+		{
+			"No match",
+			func() interface{} {
+				ch := make(chan interface{}, 1)
+				ch <- "Hello"
+				return ch
+			},
+			reflect.TypeOf(0),
+			"Hello",
+			false,
+			Ok,
+		},
+		{
+			"Interface match",
+			func() interface{} {
+				ch := make(chan interface{}, 1)
+				ch <- errors.New("oof")
+				return ch
+			},
+			errType,
+			errors.New("oof"),
+			true,
+			Ok,
+		},
+		{
+			"Closed",
+			func() interface{} {
+				ch := make(chan interface{})
+				close(ch)
+				return ch
+			},
+			reflect.TypeOf(0),
+			nil,
+			false,
+			Closed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, match, stat := RecvTyped(tt.chFactory(), tt.want)
+			if !reflect.DeepEqual(x, tt.wantX) {
+				t.Errorf("RecvTyped() x = %v, want %v", x, tt.wantX)
+			}
+			if match != tt.wantMatch {
+				t.Errorf("RecvTyped() match = %v, want %v", match, tt.wantMatch)
+			}
+			if stat != tt.wantStat {
+				t.Errorf("RecvTyped() stat = %v, want %v", stat, tt.wantStat)
+			}
+		})
+	}
+}
+
+func TestDrainMatching(t *testing.T) {
+	ch := make(chan interface{}, 5)
+	ch <- 1
+	ch <- "a"
+	ch <- 2
+	ch <- fmt.Errorf("b")
+	ch <- 3
+
+	got := DrainMatching(ch, reflect.TypeOf(0))
+	if want := []interface{}{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DrainMatching() = %v, want %v", got, want)
+	}
+	if len(ch) != 0 {
+		t.Errorf("%d values left in channel", len(ch))
+	}
+}
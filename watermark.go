@@ -0,0 +1,106 @@
+package chops
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// MakeWatermarkBuffer forwards values from the channel in to
+// the unbuffered channel out through an internal queue that
+// holds up to size values, and reports the depth of that
+// queue with edge-triggered signals for flow control.
+//
+// highSig receives a value when the queue fills to high
+// values, and lowSig receives a value when it then drains to
+// low values. Between the two, no further signals are sent,
+// so that the signals alternate and each fires once per
+// crossing. Upstream producers can pause on highSig and
+// resume on lowSig.
+//
+// Each signal channel has a buffer of one and is sent on
+// without blocking, so the signals never hold up the data
+// path. If a signal is not received before it fires again,
+// the two firings are coalesced. When in is closed, the
+// queue is drained to out, then out and both signal channels
+// are closed.
+//
+// MakeWatermarkBuffer will panic unless
+// `0 <= low < high <= size`, or if in is not a channel.
+func MakeWatermarkBuffer(size, high, low int, in interface{}, opts ...Option) (
+	out chan interface{}, highSig, lowSig <-chan struct{}) {
+	if low < 0 || low >= high || high > size {
+		panic(fmt.Sprintf("invalid watermarks: size=%d high=%d low=%d",
+			size, high, low))
+	}
+	o := applyOptions(opts)
+	inv := assertChanValue(in)
+
+	out = make(chan interface{})
+	hi := make(chan struct{}, 1)
+	lo := make(chan struct{}, 1)
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(lo)
+		defer close(hi)
+		defer close(out)
+
+		const recvIdx, sendIdx = 0, 1
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv},
+			{Dir: reflect.SelectRecv},
+		}
+		outv := reflect.ValueOf(out)
+		queue := make([]interface{}, 0, size)
+		inOpen := true
+		above := false
+
+		for inOpen || len(queue) > 0 {
+			if inOpen && len(queue) < size {
+				cases[recvIdx].Chan = inv
+			} else {
+				cases[recvIdx].Chan = reflect.Value{}
+			}
+			if len(queue) > 0 {
+				cases[sendIdx] = reflect.SelectCase{
+					Dir:  reflect.SelectSend,
+					Chan: outv,
+					Send: reflect.ValueOf(&queue[0]).Elem(),
+				}
+			} else {
+				cases[sendIdx] = reflect.SelectCase{Dir: reflect.SelectRecv}
+			}
+
+			i, x, ok := reflect.Select(cases)
+			switch {
+			case i == sendIdx:
+				o.traceSend()
+				queue[0] = nil
+				queue = queue[1:]
+				if above && len(queue) <= low {
+					above = false
+					signal(lo)
+				}
+			case !ok:
+				inOpen = false
+			default:
+				o.traceRecv(op)
+				queue = append(queue, x.Interface())
+				if !above && len(queue) >= high {
+					above = true
+					signal(hi)
+				}
+			}
+		}
+	})
+
+	return out, hi, lo
+}
+
+// signal sends on ch without blocking.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,86 @@
+package chops
+
+import (
+	"testing"
+	"time"
+)
+
+func expectSignal(t *testing.T, name string, sig <-chan struct{}, want bool) {
+	t.Helper()
+	wait := 10 * time.Millisecond
+	if want {
+		wait = time.Second
+	}
+	select {
+	case <-sig:
+		if !want {
+			t.Fatalf("%s fired unexpectedly", name)
+		}
+	case <-time.After(wait):
+		if want {
+			t.Fatalf("%s did not fire", name)
+		}
+	}
+}
+
+func TestMakeWatermarkBuffer(t *testing.T) {
+	in := make(chan int)
+	out, highSig, lowSig := MakeWatermarkBuffer(10, 8, 2, in)
+
+	for i := 0; i < 7; i++ {
+		in <- i
+	}
+	expectSignal(t, "highSig", highSig, false)
+	in <- 7
+	expectSignal(t, "highSig", highSig, true)
+	in <- 8
+	in <- 9
+
+	// Draining through the band fires nothing until low
+	for i := 0; i < 7; i++ {
+		if x := <-out; x != i {
+			t.Fatalf("received %v, want %d", x, i)
+		}
+	}
+	expectSignal(t, "lowSig", lowSig, false)
+	expectSignal(t, "highSig", highSig, false)
+	<-out
+	expectSignal(t, "lowSig", lowSig, true)
+
+	// Filling back into the band fires nothing until high
+	for i := 0; i < 5; i++ {
+		in <- i
+	}
+	expectSignal(t, "highSig", highSig, false)
+	in <- 5
+	expectSignal(t, "highSig", highSig, true)
+	expectSignal(t, "lowSig", lowSig, false)
+
+	close(in)
+	n := 0
+	for range out {
+		n++
+	}
+	if n != 8 {
+		t.Errorf("drained %d values after close, want 8", n)
+	}
+	if _, ok := <-highSig; ok {
+		t.Error("highSig not closed")
+	}
+	// The final drain crossed low
+	if _, ok := <-lowSig; !ok {
+		t.Error("lowSig did not fire while draining")
+	}
+	if _, ok := <-lowSig; ok {
+		t.Error("lowSig not closed")
+	}
+}
+
+func TestMakeWatermarkBufferInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MakeWatermarkBuffer() did not panic")
+		}
+	}()
+	MakeWatermarkBuffer(10, 2, 8, make(chan int))
+}
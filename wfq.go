@@ -0,0 +1,144 @@
+package chops
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// MakeFanInWFQ merges the channels in chs into a single output
+// channel with capacity outCap, sharing the output between
+// classes of inputs by weighted fair queueing. classOf names
+// the class of the input at each index of chs, and weights
+// gives each class its share: over time, a class with weight 3
+// sends three times as many values as a class with weight 1,
+// as long as both have values ready. No class with values
+// ready is starved.
+//
+// Each class has a virtual finish time that advances by
+// 1/weight for every value it sends, and the virtual time of
+// the merge is the finish time of the last value sent. The
+// merge holds at most one value from each input, and sends
+// next from the class whose finish time would be earliest,
+// taking turns between the inputs of that class. A class that
+// was idle resumes from the current virtual time, so it cannot
+// save up its share while it has nothing to send. When every
+// input is closed, the output is closed.
+//
+// Options may be given among the channels in chs, and don't
+// count towards the indexes passed to classOf.
+//
+// MakeFanInWFQ will panic if any input's class does not have a
+// positive weight, or if any other element of chs is not a
+// channel.
+func MakeFanInWFQ(outCap int, classOf func(index int) string,
+	weights map[string]int, chs ...interface{}) chan interface{} {
+	chs, opts := splitOptions(chs)
+	o := applyOptions(opts)
+	cases := recvCases(chs)
+	class := make([]string, len(chs))
+	for i := range chs {
+		class[i] = classOf(i)
+		if weights[class[i]] <= 0 {
+			panic(fmt.Sprintf("class %q of input %d has no positive weight",
+				class[i], i))
+		}
+	}
+	out := make(chan interface{}, outCap)
+
+	o.spawn(func(op *operator) {
+		defer o.traceClose()
+		defer close(out)
+
+		heads := make([]interface{}, len(chs))
+		hasHead := make([]bool, len(chs))
+		finish := make(map[string]float64)
+		backlogged := make(map[string]bool)
+		next := make(map[string]int) // round-robin position per class
+		var now float64
+		remaining := len(chs)
+
+		for {
+			// Top up the heads without blocking
+			ready := 0
+			for i := range cases {
+				if !hasHead[i] && cases[i].Chan.IsValid() {
+					x, ok := cases[i].Chan.TryRecv()
+					if ok {
+						o.traceRecv(op)
+						heads[i], hasHead[i] = x.Interface(), true
+					} else if x.IsValid() {
+						cases[i].Chan = reflect.Value{}
+						remaining--
+					}
+				}
+				if hasHead[i] {
+					ready++
+				}
+			}
+
+			if ready == 0 {
+				if remaining == 0 {
+					return
+				}
+				i, x, ok := doSelect(cases)
+				if !ok {
+					cases[i].Chan = reflect.Value{}
+					remaining--
+				} else {
+					o.traceRecv(op)
+					heads[i], hasHead[i] = x.Interface(), true
+				}
+				continue
+			}
+
+			// A class that was idle catches up to the current
+			// virtual time, then picks the earliest finish
+			var best string
+			var bestFinish float64
+			found := false
+			for i := range chs {
+				if !hasHead[i] {
+					continue
+				}
+				c := class[i]
+				if !backlogged[c] {
+					backlogged[c] = true
+					if finish[c] < now {
+						finish[c] = now
+					}
+				}
+				f := finish[c] + 1/float64(weights[c])
+				if !found || f < bestFinish {
+					best, bestFinish, found = c, f, true
+				}
+			}
+
+			// Take turns between the ready inputs of that class
+			pick := -1
+			for k := 0; k < len(chs); k++ {
+				i := (next[best] + k) % len(chs)
+				if hasHead[i] && class[i] == best {
+					pick = i
+					break
+				}
+			}
+			next[best] = pick + 1
+
+			finish[best] = bestFinish
+			now = bestFinish
+			out <- heads[pick]
+			o.traceSend()
+			heads[pick], hasHead[pick] = nil, false
+
+			backlogged[best] = false
+			for i := range chs {
+				if hasHead[i] && class[i] == best {
+					backlogged[best] = true
+					break
+				}
+			}
+		}
+	})
+
+	return out
+}
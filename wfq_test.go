@@ -0,0 +1,83 @@
+package chops
+
+import "testing"
+
+func TestMakeFanInWFQ(t *testing.T) {
+	const n = 400
+	chs := make([]interface{}, 3)
+	for i := range chs {
+		ch := make(chan string, n)
+		for j := 0; j < n; j++ {
+			ch <- []string{"gold", "gold", "bronze"}[i]
+		}
+		close(ch)
+		chs[i] = ch
+	}
+	classOf := func(i int) string {
+		if i < 2 {
+			return "gold"
+		}
+		return "bronze"
+	}
+	weights := map[string]int{"gold": 3, "bronze": 1}
+	out := MakeFanInWFQ(0, classOf, weights, chs...)
+
+	// Every input always has values ready, so the first n
+	// values are split 3:1
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		counts[(<-out).(string)]++
+	}
+	if g := counts["gold"]; g < n*3/4-10 || g > n*3/4+10 {
+		t.Errorf("gold sent %d of %d, want about %d", g, n, n*3/4)
+	}
+
+	// Once gold runs dry, bronze gets everything
+	rest := 0
+	for range out {
+		rest++
+	}
+	if rest != 3*n-n {
+		t.Errorf("received %d more values, want %d", rest, 2*n)
+	}
+}
+
+func TestMakeFanInWFQNoWeight(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MakeFanInWFQ() did not panic")
+		}
+	}()
+	MakeFanInWFQ(0, func(int) string { return "missing" },
+		map[string]int{}, make(chan int))
+}
+
+func TestMakeFanInWFQEmptyClass(t *testing.T) {
+	const n = 100
+	chs := make([]interface{}, 2)
+	for i := range chs {
+		ch := make(chan int, n)
+		for j := 0; j < n; j++ {
+			ch <- i
+		}
+		close(ch)
+		chs[i] = ch
+	}
+	classOf := func(i int) string {
+		return []string{"", "named"}[i]
+	}
+	out := MakeFanInWFQ(0, classOf, map[string]int{"": 1, "named": 1}, chs...)
+
+	// The class named "" gets its share like any other
+	unnamed := 0
+	for i := 0; i < n; i++ {
+		if (<-out).(int) == 0 {
+			unnamed++
+		}
+	}
+	if unnamed < n/2-5 || unnamed > n/2+5 {
+		t.Errorf("class \"\" sent %d of %d, want about %d", unnamed, n, n/2)
+	}
+	for range out {
+	}
+}